@@ -0,0 +1,280 @@
+package algo
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// OnChaseType 追单结束(全部成交/停止)
+type OnChaseType func(c *Chase)
+
+// Chase 追单: 以对手价限价委托, 超时或对手价变化时撤单, 撤单成功后以新的对手价重新委托, 直至全部成交或达到限制
+type Chase struct {
+	InstrumentID string
+	Direction    goctp.DirectionType
+	OffsetFlag   goctp.OffsetFlagType
+	HedgeFlag    goctp.HedgeFlagType // 空为 HFTrade.HedgeFlag
+	Volume       int
+	Timeout      time.Duration // 委托未全部成交时撤单重发的时间, 默认 3s
+	CancelOnMove bool          // 对手价变化时撤单重发, 默认 true
+	MaxOrders    int           // 最大委托次数, 0 不限, 达到后停止
+	PriceLimit   float64       // 买不高于/卖不低于此价, 0 不限, 超出时暂停委托
+	Ticks        int           // 委托价为对手价加(买)/减(卖) Ticks 个最小变动价位
+
+	Status Status
+	Traded int      // 已成交数量
+	Orders []string // 发出的委托 (HFTrade.Orders 的 key)
+	Msg    string   // 停止原因
+
+	e         *Executor
+	mu        sync.Mutex
+	orderID   string  // 当前委托
+	price     float64 // 当前委托价
+	traded    int     // 已完成委托的成交数量
+	canceling bool    // 当前委托撤单中
+	stopping  bool    // 停止中(等待撤单)
+	timer     *time.Timer
+	onDone    OnChaseType
+	parent    *Slice // 所属拆单
+}
+
+// NewChase 追单, 设置参数后 Start
+func (e *Executor) NewChase(instrument string, buySell goctp.DirectionType, openClose goctp.OffsetFlagType, volume int) *Chase {
+	return &Chase{
+		InstrumentID: instrument,
+		Direction:    buySell,
+		OffsetFlag:   openClose,
+		Volume:       volume,
+		Timeout:      3 * time.Second,
+		CancelOnMove: true,
+		e:            e,
+	}
+}
+
+// RegOnDone 注册追单结束响应
+func (c *Chase) RegOnDone(on OnChaseType) {
+	c.onDone = on
+}
+
+// Start 开始追单, 无行情时等待首笔行情
+func (c *Chase) Start() {
+	c.mu.Lock()
+	if c.Status != StatusWaiting {
+		c.mu.Unlock()
+		return
+	}
+	c.Status = StatusRunning
+	c.e.start(c)
+	var done bool
+	if tick := c.e.Tick(c.InstrumentID); tick != nil {
+		done = c.place(tick)
+	}
+	c.mu.Unlock()
+	c.done(done)
+}
+
+// Stop 停止追单: 撤销当前委托, 撤单完成后响应 RegOnDone
+func (c *Chase) Stop() {
+	c.mu.Lock()
+	done := c.halt("手动停止")
+	c.mu.Unlock()
+	c.done(done)
+}
+
+// Left 剩余数量
+func (c *Chase) Left() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Volume - c.Traded
+}
+
+func (c *Chase) instrument() string {
+	return c.InstrumentID
+}
+
+func (c *Chase) onTick(tick *goctp.TickField) {
+	c.mu.Lock()
+	done := c.poll()
+	if !done && c.Status == StatusRunning && !c.stopping {
+		if len(c.orderID) == 0 {
+			done = c.place(tick)
+		} else if price := c.orderPrice(tick); c.CancelOnMove && !c.canceling && goctp.ValidPrice(price) && price != c.price { // 对手价变化
+			c.cancel()
+		}
+	}
+	c.mu.Unlock()
+	c.done(done)
+}
+
+func (c *Chase) onOrder(field *goctp.OrderField, info *goctp.RspInfoField) {
+	c.mu.Lock()
+	done := c.update(field, info)
+	c.mu.Unlock()
+	c.done(done)
+}
+
+func (c *Chase) onTimeout(id string) {
+	c.mu.Lock()
+	done := c.poll()
+	if !done && c.orderID == id && !c.canceling {
+		c.cancel()
+	}
+	c.mu.Unlock()
+	c.done(done)
+}
+
+// poll 由 HFTrade.Orders 更新当前委托(委托响应可能早于 addOrder)
+func (c *Chase) poll() bool {
+	if len(c.orderID) == 0 {
+		return false
+	}
+	if of, ok := c.e.t.Orders.Load(c.orderID); ok {
+		return c.update(of.(*goctp.OrderField), nil)
+	}
+	return false
+}
+
+// place 以对手价委托(数量不超过限价单最大下单量), 非连续交易时等待, 返回是否结束
+func (c *Chase) place(tick *goctp.TickField) bool {
+	if len(c.orderID) > 0 || c.Status != StatusRunning {
+		return false
+	}
+	left := c.Volume - c.traded
+	if left <= 0 {
+		return c.finish(StatusFinished, "全部成交")
+	}
+	if c.MaxOrders > 0 && len(c.Orders) >= c.MaxOrders {
+		return c.finish(StatusStopped, "达到最大委托次数")
+	}
+	if !c.e.tradable(c.InstrumentID) {
+		return false
+	}
+	price := c.orderPrice(tick)
+	if !goctp.ValidPrice(price) { // 涨跌停时无对手价
+		return false
+	}
+	if c.PriceLimit > 0 && (c.Direction == goctp.DirectionBuy && price > c.PriceLimit || c.Direction == goctp.DirectionSell && price < c.PriceLimit) {
+		return false
+	}
+	if inst := c.e.instrument(c.InstrumentID); inst != nil && inst.MaxLimitOrderVolume > 0 && left > inst.MaxLimitOrderVolume {
+		left = inst.MaxLimitOrderVolume
+	}
+	req := goctp.NewOrderRequest(c.InstrumentID, c.Direction, c.OffsetFlag, price, left)
+	if c.HedgeFlag != 0 {
+		req.SetHedgeFlag(c.HedgeFlag)
+	}
+	id := c.e.t.ReqOrderInsertRequest(req)
+	c.orderID, c.price, c.canceling = id, price, false
+	c.Orders = append(c.Orders, id)
+	c.e.addOrder(id, c)
+	c.timer = time.AfterFunc(c.Timeout, func() { c.onTimeout(id) })
+	return false
+}
+
+// update 当前委托状态变化, 返回是否结束
+func (c *Chase) update(field *goctp.OrderField, info *goctp.RspInfoField) bool {
+	if len(c.orderID) == 0 || orderID(field) != c.orderID {
+		return false
+	}
+	filled := field.VolumeTotalOriginal - field.VolumeLeft
+	c.Traded = c.traded + filled
+	if field.OrderStatus != goctp.OrderStatusAllTraded && field.OrderStatus != goctp.OrderStatusCanceled {
+		return false
+	}
+	// 当前委托完成
+	c.timer.Stop()
+	c.e.removeOrder(c.orderID)
+	c.traded += filled
+	c.orderID = ""
+	if c.traded >= c.Volume {
+		return c.finish(StatusFinished, "全部成交")
+	}
+	if c.stopping {
+		return c.finish(StatusStopped, c.Msg)
+	}
+	if field.OrderStatus == goctp.OrderStatusCanceled && !c.canceling { // 被拒绝或被他人撤单
+		msg := field.StatusMsg
+		if info != nil {
+			msg = info.ErrorMsg
+		}
+		return c.finish(StatusStopped, msg)
+	}
+	if tick := c.e.Tick(c.InstrumentID); tick != nil {
+		return c.place(tick)
+	}
+	return false
+}
+
+// halt 停止, 有委托时先撤单
+func (c *Chase) halt(msg string) bool {
+	if c.Status != StatusRunning || c.stopping {
+		return false
+	}
+	c.Msg = msg
+	if len(c.orderID) == 0 {
+		return c.finish(StatusStopped, msg)
+	}
+	c.stopping = true
+	if !c.canceling {
+		c.cancel()
+	}
+	return false
+}
+
+// cancel 撤销当前委托, 撤单未发出(如撤单数达到上限)时重新计时, 由之后的行情/超时重试
+func (c *Chase) cancel() {
+	c.canceling = c.e.t.ReqOrderAction(c.orderID) == 0
+	if !c.canceling {
+		id := c.orderID
+		c.timer.Stop()
+		c.timer = time.AfterFunc(c.Timeout, func() { c.onTimeout(id) })
+	}
+}
+
+func (c *Chase) finish(status Status, msg string) bool {
+	c.Status, c.Msg = status, msg
+	c.e.stop(c)
+	return true
+}
+
+func (c *Chase) done(done bool) {
+	if c.parent != nil {
+		c.parent.onChild(c, done)
+	}
+	if done && c.onDone != nil {
+		c.onDone(c)
+	}
+}
+
+// opposite 对手价
+func (c *Chase) opposite(tick *goctp.TickField) float64 {
+	if c.Direction == goctp.DirectionBuy {
+		return tick.AskPrice1
+	}
+	return tick.BidPrice1
+}
+
+// orderPrice 委托价: 对手价加减 Ticks 个最小变动价位, 按最小变动价位取整并限制在涨跌停板内
+func (c *Chase) orderPrice(tick *goctp.TickField) float64 {
+	price := c.opposite(tick)
+	inst := c.e.instrument(c.InstrumentID)
+	if !goctp.ValidPrice(price) || inst == nil || inst.PriceTick <= 0 {
+		return price
+	}
+	if c.Direction == goctp.DirectionBuy {
+		price += float64(c.Ticks) * inst.PriceTick
+	} else {
+		price -= float64(c.Ticks) * inst.PriceTick
+	}
+	price = math.Round(price/inst.PriceTick) * inst.PriceTick
+	if goctp.ValidPrice(tick.UpperLimitPrice) && price > tick.UpperLimitPrice {
+		price = tick.UpperLimitPrice
+	}
+	if goctp.ValidPrice(tick.LowerLimitPrice) && price < tick.LowerLimitPrice {
+		price = tick.LowerLimitPrice
+	}
+	return price
+}
@@ -0,0 +1,167 @@
+package algo
+
+import (
+	"fmt"
+	"sync"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// Status 算法状态
+type Status int
+
+const (
+	StatusWaiting  Status = iota // 未启动
+	StatusRunning                // 执行中
+	StatusFinished               // 全部成交
+	StatusStopped                // 已停止(手动/达到限制/委托被拒绝)
+)
+
+// tickHandler 由行情驱动的算法
+type tickHandler interface {
+	instrument() string
+	onTick(tick *goctp.TickField)
+}
+
+// Executor 执行算法: 由行情(Attach/Update)及委托响应(AttachTrade/UpdateOrder)驱动
+type Executor struct {
+	t *goctp.HFTrade
+
+	mu       sync.Mutex
+	ticks    map[string]*goctp.TickField // 合约最新行情
+	handlers map[tickHandler]struct{}    // 执行中的算法
+	orders   map[string]*Chase           // 委托(HFTrade.Orders 的 key):所属追单
+}
+
+// NewExecutor 实例化
+func NewExecutor(t *goctp.HFTrade) *Executor {
+	return &Executor{
+		t:        t,
+		ticks:    make(map[string]*goctp.TickField),
+		handlers: make(map[tickHandler]struct{}),
+		orders:   make(map[string]*Chase),
+	}
+}
+
+// Attach 挂接到行情接口(替代 RegOnTick), 先驱动算法再调用 on(可为 nil)
+func (e *Executor) Attach(q *goctp.HFQuote, on goctp.OnTickType) {
+	q.RegOnTick(func(tick *goctp.TickField) {
+		e.Update(tick)
+		if on != nil {
+			on(tick)
+		}
+	})
+}
+
+// AttachTrade 挂接到交易接口(替代 RegOnRtnOrder/RegOnRtnCancel/RegOnErrRtnOrder), 先驱动算法再调用 on*(可为 nil)
+func (e *Executor) AttachTrade(onRtnOrder, onRtnCancel goctp.OnRtnOrderType, onErrRtnOrder goctp.OnRtnErrOrderType) {
+	e.t.RegOnRtnOrder(func(field *goctp.OrderField) {
+		e.UpdateOrder(field)
+		if onRtnOrder != nil {
+			onRtnOrder(field)
+		}
+	})
+	e.t.RegOnRtnCancel(func(field *goctp.OrderField) {
+		e.UpdateOrder(field)
+		if onRtnCancel != nil {
+			onRtnCancel(field)
+		}
+	})
+	e.t.RegOnErrRtnOrder(func(field *goctp.OrderField, info *goctp.RspInfoField) {
+		e.UpdateErrOrder(field, info)
+		if onErrRtnOrder != nil {
+			onErrRtnOrder(field, info)
+		}
+	})
+}
+
+// Update 处理一笔行情
+func (e *Executor) Update(tick *goctp.TickField) {
+	e.mu.Lock()
+	e.ticks[tick.InstrumentID] = tick
+	var hs []tickHandler
+	for h := range e.handlers {
+		if h.instrument() == tick.InstrumentID {
+			hs = append(hs, h)
+		}
+	}
+	e.mu.Unlock()
+	for _, h := range hs {
+		h.onTick(tick)
+	}
+}
+
+// UpdateOrder 处理委托响应(成交/撤单)
+func (e *Executor) UpdateOrder(field *goctp.OrderField) {
+	if c := e.owner(field); c != nil {
+		c.onOrder(field, nil)
+	}
+}
+
+// UpdateErrOrder 处理错误委托
+func (e *Executor) UpdateErrOrder(field *goctp.OrderField, info *goctp.RspInfoField) {
+	if c := e.owner(field); c != nil {
+		c.onOrder(field, info)
+	}
+}
+
+// Tick 合约最新行情
+func (e *Executor) Tick(instrument string) *goctp.TickField {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.ticks[instrument]
+}
+
+// instrument 合约信息, 未查询到时为 nil
+func (e *Executor) instrument(id string) *goctp.InstrumentField {
+	if inst, ok := e.t.Instruments.Load(id); ok {
+		return inst.(*goctp.InstrumentField)
+	}
+	return nil
+}
+
+// tradable 合约(或其品种)处于连续交易, 无状态时视为可交易
+func (e *Executor) tradable(id string) bool {
+	status, ok := e.t.InstrumentStatuss.Load(id)
+	if !ok {
+		if inst := e.instrument(id); inst != nil {
+			status, ok = e.t.InstrumentStatuss.Load(inst.ProductID)
+		}
+	}
+	return !ok || status.(*goctp.InstrumentStatus).InstrumentStatus == goctp.InstrumentStatusContinous
+}
+
+func (e *Executor) owner(field *goctp.OrderField) *Chase {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.orders[orderID(field)]
+}
+
+func (e *Executor) start(h tickHandler) {
+	e.mu.Lock()
+	e.handlers[h] = struct{}{}
+	e.mu.Unlock()
+}
+
+func (e *Executor) stop(h tickHandler) {
+	e.mu.Lock()
+	delete(e.handlers, h)
+	e.mu.Unlock()
+}
+
+func (e *Executor) addOrder(id string, c *Chase) {
+	e.mu.Lock()
+	e.orders[id] = c
+	e.mu.Unlock()
+}
+
+func (e *Executor) removeOrder(id string) {
+	e.mu.Lock()
+	delete(e.orders, id)
+	e.mu.Unlock()
+}
+
+// orderID 委托在 HFTrade.Orders 中的 key
+func orderID(field *goctp.OrderField) string {
+	return fmt.Sprintf("%d_%s", field.SessionID, field.OrderRef)
+}
@@ -0,0 +1,225 @@
+package algo
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// OnSliceType 拆单进度(子单成交/结束)
+type OnSliceType func(s *Slice)
+
+// Slice 拆单(TWAP/VWAP): 在 Duration 内按 Weights 将 Volume 分为若干时段, 各时段应完成的数量以追单(Chase)执行,
+// 子单每笔委托不超过限价单最大下单量, 非连续交易时暂停, 未完成的数量并入下一时段
+type Slice struct {
+	InstrumentID string
+	Direction    goctp.DirectionType
+	OffsetFlag   goctp.OffsetFlagType
+	HedgeFlag    goctp.HedgeFlagType // 空为 HFTrade.HedgeFlag
+	Volume       int
+	Duration     time.Duration // 执行时长, 结束时仍未完成的数量继续追单
+	Weights      []float64     // 各时段数量占比: TWAP 等分, VWAP 为成交量分布(如历史各时段成交量)
+	Timeout      time.Duration // 子单撤单重发的时间, 默认 3s
+	Ticks        int           // 子单委托价为对手价加(买)/减(卖) Ticks 个最小变动价位
+	PriceLimit   float64       // 买不高于/卖不低于此价, 0 不限
+
+	Status Status
+	Traded int      // 已成交数量
+	Paused bool     // 非连续交易暂停中
+	Chases []*Chase // 子单
+	Msg    string   // 停止原因
+
+	e          *Executor
+	mu         sync.Mutex
+	begin      time.Time
+	child      *Chase // 执行中的子单
+	traded     int    // 已结束子单的成交数量
+	stopping   bool
+	onProgress OnSliceType
+	onDone     OnSliceType
+}
+
+// NewTWAP 时间加权拆单: duration 内等分为 slices 个时段
+func (e *Executor) NewTWAP(instrument string, buySell goctp.DirectionType, openClose goctp.OffsetFlagType, volume int, duration time.Duration, slices int) *Slice {
+	if slices < 1 {
+		slices = 1
+	}
+	weights := make([]float64, slices)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return e.newSlice(instrument, buySell, openClose, volume, duration, weights)
+}
+
+// NewVWAP 成交量加权拆单: duration 内等分为 len(weights) 个时段, 各时段数量按 weights(成交量分布)分配
+func (e *Executor) NewVWAP(instrument string, buySell goctp.DirectionType, openClose goctp.OffsetFlagType, volume int, duration time.Duration, weights []float64) *Slice {
+	return e.newSlice(instrument, buySell, openClose, volume, duration, weights)
+}
+
+func (e *Executor) newSlice(instrument string, buySell goctp.DirectionType, openClose goctp.OffsetFlagType, volume int, duration time.Duration, weights []float64) *Slice {
+	return &Slice{
+		InstrumentID: instrument,
+		Direction:    buySell,
+		OffsetFlag:   openClose,
+		Volume:       volume,
+		Duration:     duration,
+		Weights:      weights,
+		Timeout:      3 * time.Second,
+		e:            e,
+	}
+}
+
+// RegOnProgress 注册拆单进度响应
+func (s *Slice) RegOnProgress(on OnSliceType) {
+	s.onProgress = on
+}
+
+// RegOnDone 注册拆单结束响应
+func (s *Slice) RegOnDone(on OnSliceType) {
+	s.onDone = on
+}
+
+// Start 开始拆单, 由行情驱动
+func (s *Slice) Start() {
+	s.mu.Lock()
+	if s.Status != StatusWaiting {
+		s.mu.Unlock()
+		return
+	}
+	s.Status = StatusRunning
+	s.begin = time.Now()
+	s.e.start(s)
+	c := s.next()
+	s.mu.Unlock()
+	if c != nil {
+		c.Start()
+	}
+}
+
+// Stop 停止拆单: 停止执行中的子单, 子单结束后响应 RegOnDone
+func (s *Slice) Stop() {
+	s.mu.Lock()
+	if s.Status != StatusRunning || s.stopping {
+		s.mu.Unlock()
+		return
+	}
+	s.stopping, s.Msg = true, "手动停止"
+	c := s.child
+	var done bool
+	if c == nil {
+		done = s.finish(StatusStopped, s.Msg)
+	}
+	s.mu.Unlock()
+	if c != nil {
+		c.Stop()
+	}
+	s.done(done)
+}
+
+// Target 当前时段应完成的数量
+func (s *Slice) Target() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.target(time.Now())
+}
+
+func (s *Slice) instrument() string {
+	return s.InstrumentID
+}
+
+func (s *Slice) onTick(tick *goctp.TickField) {
+	s.mu.Lock()
+	c := s.next()
+	s.mu.Unlock()
+	if c != nil {
+		c.Start()
+	}
+}
+
+// onChild 子单成交/结束
+func (s *Slice) onChild(c *Chase, done bool) {
+	traded := c.Volume - c.Left()
+	s.mu.Lock()
+	if c != s.child || (!done && s.traded+traded == s.Traded) {
+		s.mu.Unlock()
+		return
+	}
+	s.Traded = s.traded + traded
+	var finished bool
+	var next *Chase
+	if done {
+		s.traded += traded
+		s.child = nil
+		if s.traded >= s.Volume {
+			finished = s.finish(StatusFinished, "全部成交")
+		} else if s.stopping {
+			finished = s.finish(StatusStopped, s.Msg)
+		} else if c.Status == StatusStopped { // 子单被拒绝或达到限制
+			finished = s.finish(StatusStopped, c.Msg)
+		} else {
+			next = s.next()
+		}
+	}
+	s.mu.Unlock()
+	if s.onProgress != nil {
+		s.onProgress(s)
+	}
+	s.done(finished)
+	if next != nil {
+		next.Start()
+	}
+}
+
+// next 落后于计划时生成子单(由调用方在解锁后 Start)
+func (s *Slice) next() *Chase {
+	if s.Status != StatusRunning || s.stopping {
+		return nil
+	}
+	if s.Paused = !s.e.tradable(s.InstrumentID); s.Paused || s.child != nil {
+		return nil
+	}
+	volume := s.target(time.Now()) - s.traded
+	if volume <= 0 {
+		return nil
+	}
+	c := s.e.NewChase(s.InstrumentID, s.Direction, s.OffsetFlag, volume)
+	c.HedgeFlag, c.Timeout, c.Ticks, c.PriceLimit = s.HedgeFlag, s.Timeout, s.Ticks, s.PriceLimit
+	c.parent = s
+	s.child = c
+	s.Chases = append(s.Chases, c)
+	return c
+}
+
+// target 截至 now 所在时段(含)应完成的数量
+func (s *Slice) target(now time.Time) int {
+	elapsed := now.Sub(s.begin)
+	if len(s.Weights) == 0 || elapsed >= s.Duration {
+		return s.Volume
+	}
+	idx := int(elapsed * time.Duration(len(s.Weights)) / s.Duration)
+	var sum, cum float64
+	for i, w := range s.Weights {
+		sum += w
+		if i <= idx {
+			cum += w
+		}
+	}
+	if sum <= 0 {
+		return s.Volume
+	}
+	return int(math.Round(float64(s.Volume) * cum / sum))
+}
+
+func (s *Slice) finish(status Status, msg string) bool {
+	s.Status, s.Msg = status, msg
+	s.e.stop(s)
+	return true
+}
+
+func (s *Slice) done(done bool) {
+	if done && s.onDone != nil {
+		s.onDone(s)
+	}
+}
@@ -0,0 +1,286 @@
+package bar
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// Period 周期(分钟)
+type Period int
+
+const (
+	Min1  Period = 1
+	Min5  Period = 5
+	Min15 Period = 15
+	Hour1 Period = 60
+	Day   Period = 24 * 60 // 交易日(含夜盘)
+)
+
+// auctionMinutes 开盘前此分钟数内的行情(集合竞价)计入开盘的第一根 bar
+const auctionMinutes = 5
+
+// Bar K线
+type Bar struct {
+	InstrumentID string
+	Period       Period
+	TradingDay   string // 交易日
+	ActionDay    string // 自然日(bar 开始时)
+	Time         string // 开始时间 HH:MM:00, 日线为首个交易时段的开始时间
+	Open         float64
+	High         float64
+	Low          float64
+	Close        float64
+	Volume       int     // 成交量(由累计成交量计算增量)
+	Turnover     float64 // 成交金额
+	OpenInterest float64 // 持仓量(最后一笔)
+}
+
+// OnBarType bar 完成
+type OnBarType func(bar *Bar)
+
+// Builder 由 tick 合成 K 线. 按自然时间对齐周期(如 1h 为整点), 按品种交易时段处理休盘与夜盘:
+// 休盘/收盘时刻的 tick 计入前一根 bar, 集合竞价的 tick 计入开盘的第一根 bar,
+// 收盘 tick 到达即完成休盘期间不再更新的 bar, 不必等到下一时段开盘
+type Builder struct {
+	periods []Period
+	onBar   OnBarType
+
+	mu    sync.Mutex
+	insts map[string]*instBars
+}
+
+// instBars 一个合约各周期的当前 bar
+type instBars struct {
+	tradingDay string
+	sessions   []session
+	volume     int // 上一笔的累计成交量, -1 未知
+	turnover   float64
+	bars       map[Period]*Bar
+	starts     map[Period]int // 当前 bar 的开始分钟
+	closed     map[Period]int // 已完成的最后一根 bar 的开始分钟, 其后到达的同一 bar 的 tick 忽略
+}
+
+// NewBuilder 实例化, periods 为空时合成全部周期
+func NewBuilder(periods ...Period) *Builder {
+	if len(periods) == 0 {
+		periods = []Period{Min1, Min5, Min15, Hour1, Day}
+	}
+	return &Builder{
+		periods: periods,
+		insts:   make(map[string]*instBars),
+	}
+}
+
+// RegOnBar 注册 bar 完成响应
+func (b *Builder) RegOnBar(on OnBarType) {
+	b.onBar = on
+}
+
+// Attach 挂接到行情接口(替代 RegOnTick), 先合成再调用 on(可为 nil)
+func (b *Builder) Attach(q *goctp.HFQuote, on goctp.OnTickType) {
+	q.RegOnTick(func(tick *goctp.TickField) {
+		b.Update(tick)
+		if on != nil {
+			on(tick)
+		}
+	})
+}
+
+// Current 合约当前(未完成)的 bar
+func (b *Builder) Current(instrumentID string, period Period) *Bar {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ib, ok := b.insts[instrumentID]; ok {
+		if bar, ok := ib.bars[period]; ok {
+			var cp = *bar
+			return &cp
+		}
+	}
+	return nil
+}
+
+// Update 处理一笔 tick
+func (b *Builder) Update(tick *goctp.TickField) {
+	b.emit(b.update(tick))
+}
+
+// Flush 完成所有未完成的 bar(如收盘后或回放结束)
+func (b *Builder) Flush() {
+	b.mu.Lock()
+	var done []*Bar
+	for _, ib := range b.insts {
+		for _, p := range b.periods {
+			done = ib.finish(p, done)
+		}
+	}
+	b.mu.Unlock()
+	b.emit(done)
+}
+
+func (b *Builder) emit(bars []*Bar) {
+	if b.onBar == nil {
+		return
+	}
+	for _, bar := range bars {
+		b.onBar(bar)
+	}
+}
+
+func (b *Builder) update(tick *goctp.TickField) (done []*Bar) {
+	if tick.Volume <= 0 || !goctp.ValidPrice(tick.LastPrice) || len(tick.UpdateTime) < 8 { // 未成交或无效行情
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ib, ok := b.insts[tick.InstrumentID]
+	if !ok {
+		ib = &instBars{sessions: ProductHours(tick.InstrumentID).sessions(), volume: -1}
+		b.insts[tick.InstrumentID] = ib
+	}
+	if tick.TradingDay != ib.tradingDay { // 交易日切换
+		if len(ib.tradingDay) > 0 {
+			for _, p := range b.periods {
+				done = ib.finish(p, done)
+			}
+			ib.volume, ib.turnover = 0, 0
+		}
+		ib.tradingDay = tick.TradingDay
+		ib.bars = make(map[Period]*Bar)
+		ib.starts = make(map[Period]int)
+		ib.closed = make(map[Period]int)
+	}
+
+	minute, closing, next := ib.locate(minuteOf(tick.UpdateTime))
+
+	if ib.volume < 0 { // 首笔: 开盘即开始接收时取全部成交量, 否则从下一笔开始计算
+		ib.volume, ib.turnover = tick.Volume, tick.Turnover
+		if minute == ib.sessions[0].begin {
+			ib.volume, ib.turnover = 0, 0
+		}
+	}
+	volume, turnover := tick.Volume-ib.volume, tick.Turnover-ib.turnover
+	if volume < 0 {
+		volume, turnover = 0, 0
+	}
+	ib.volume, ib.turnover = tick.Volume, tick.Turnover
+
+	for _, p := range b.periods {
+		start := ib.sessions[0].begin // 日线
+		if p != Day {
+			start = floorDiv(minute, int(p)) * int(p)
+		}
+		if last, ok := ib.closed[p]; ok && start <= last {
+			continue
+		}
+		bar := ib.bars[p]
+		if bar != nil && ib.starts[p] != start { // 新 bar
+			done = ib.finish(p, done)
+			bar = nil
+		}
+		if bar == nil {
+			bar = &Bar{
+				InstrumentID: tick.InstrumentID,
+				Period:       p,
+				TradingDay:   tick.TradingDay,
+				ActionDay:    actionDay(tick),
+				Time:         timeOf(start),
+				Open:         tick.LastPrice,
+				High:         tick.LastPrice,
+				Low:          tick.LastPrice,
+			}
+			ib.bars[p] = bar
+			ib.starts[p] = start
+		}
+		bar.High = math.Max(bar.High, tick.LastPrice)
+		bar.Low = math.Min(bar.Low, tick.LastPrice)
+		bar.Close = tick.LastPrice
+		bar.Volume += volume
+		bar.Turnover += turnover
+		bar.OpenInterest = tick.OpenInterest
+	}
+
+	if closing { // 休盘/收盘: 完成在下一时段开盘前结束的 bar
+		for _, p := range b.periods {
+			if _, ok := ib.bars[p]; ok && (p != Day && ib.starts[p]+int(p) <= next || next == noNext) {
+				done = ib.finish(p, done)
+			}
+		}
+	}
+	return done
+}
+
+// noNext 已是交易日的最后一个时段
+const noNext = math.MaxInt32
+
+// locate tick 所属的 bar 分钟. closing: tick 在时段结束之后(休盘/收盘), next 为下一时段的开始
+func (ib *instBars) locate(m int) (minute int, closing bool, next int) {
+	for i, s := range ib.sessions {
+		if m < s.begin {
+			if i == 0 || s.begin-m <= auctionMinutes { // 开盘前/集合竞价
+				return s.begin, false, 0
+			}
+			return ib.sessions[i-1].end - 1, true, s.begin
+		}
+		if m < s.end {
+			return m, false, 0
+		}
+	}
+	return ib.sessions[len(ib.sessions)-1].end - 1, true, noNext
+}
+
+// finish 完成当前 bar
+func (ib *instBars) finish(p Period, done []*Bar) []*Bar {
+	bar, ok := ib.bars[p]
+	if !ok {
+		return done
+	}
+	ib.closed[p] = ib.starts[p]
+	delete(ib.bars, p)
+	delete(ib.starts, p)
+	return append(done, bar)
+}
+
+// actionDay 自然日. 大商所夜盘的 ActionDay 为交易日, 取交易日的前一工作日
+func actionDay(tick *goctp.TickField) string {
+	hour := tick.UpdateTime[:2]
+	if (hour >= "18" && tick.ActionDay == tick.TradingDay) || len(tick.ActionDay) == 0 {
+		day, err := time.Parse("20060102", tick.TradingDay)
+		if err != nil {
+			return tick.ActionDay
+		}
+		switch {
+		case hour >= "18":
+			day = prevWeekday(day)
+		case hour < "06":
+			day = prevWeekday(day).AddDate(0, 0, 1)
+		}
+		return day.Format("20060102")
+	}
+	return tick.ActionDay
+}
+
+func prevWeekday(day time.Time) time.Time {
+	day = day.AddDate(0, 0, -1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// timeOf 交易日内的分钟数转为 HH:MM:00
+func timeOf(minute int) string {
+	minute = (minute + 24*60) % (24 * 60)
+	return fmt.Sprintf("%02d:%02d:00", minute/60, minute%60)
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 && a%b != 0 {
+		return a/b - 1
+	}
+	return a / b
+}
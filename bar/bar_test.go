@@ -0,0 +1,149 @@
+package bar
+
+import (
+	"testing"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// ticks rb 按 (时间, 价格, 累计成交量) 生成行情, 交易日 20230105(周四), 夜盘自然日 20230104
+func ticks(data ...interface{}) (ts []*goctp.TickField) {
+	for i := 0; i+2 < len(data); i += 3 {
+		updateTime := data[i].(string)
+		actionDay := "20230105"
+		if updateTime >= "18" {
+			actionDay = "20230104"
+		}
+		ts = append(ts, &goctp.TickField{
+			InstrumentID: "rb2305",
+			TradingDay:   "20230105",
+			ActionDay:    actionDay,
+			UpdateTime:   updateTime,
+			LastPrice:    data[i+1].(float64),
+			Volume:       data[i+2].(int),
+		})
+	}
+	return
+}
+
+// collect 逐笔更新, 返回每笔 tick 后完成的 bar
+func collect(b *Builder, ts []*goctp.TickField) (done [][]*Bar) {
+	var bars []*Bar
+	b.RegOnBar(func(bar *Bar) { bars = append(bars, bar) })
+	for _, tick := range ts {
+		bars = nil
+		b.Update(tick)
+		done = append(done, bars)
+	}
+	return
+}
+
+func TestNightSession(t *testing.T) {
+	b := NewBuilder(Min1, Min5)
+	done := collect(b, ticks(
+		"20:59:00", 3000.0, 10, // 集合竞价: 计入 21:00
+		"21:00:10", 3005.0, 15,
+		"21:01:00", 3001.0, 20, // 完成 21:00 的 1 分钟 bar
+		"22:59:30", 3002.0, 30,
+		"23:00:00", 3003.0, 31, // 收盘 tick: 计入 22:59 并立即完成
+	))
+	if len(done[0]) != 0 || len(done[1]) != 0 {
+		t.Fatal("集合竞价及开盘第一分钟不应完成 bar")
+	}
+	if len(done[2]) != 1 {
+		t.Fatalf("21:01 应完成 1 根 bar, 实际 %d", len(done[2]))
+	}
+	first := done[2][0]
+	if first.Time != "21:00:00" || first.ActionDay != "20230104" || first.Open != 3000 || first.High != 3005 || first.Close != 3005 || first.Volume != 15 {
+		t.Fatalf("21:00 bar: %+v", *first)
+	}
+	// 22:59 的 1 分钟 bar 完成于 21:01 之后的 22:59:30, 收盘 tick 完成 22:59 的 1/5 分钟 bar
+	var closing = map[Period]*Bar{}
+	for _, bar := range done[4] {
+		closing[bar.Period] = bar
+	}
+	if bar := closing[Min1]; bar == nil || bar.Time != "22:59:00" || bar.Close != 3003 || bar.Volume != 11 {
+		t.Fatalf("收盘 1 分钟 bar: %+v", closing[Min1])
+	}
+	if bar := closing[Min5]; bar == nil || bar.Time != "22:55:00" || bar.Volume != 11 {
+		t.Fatalf("收盘 5 分钟 bar: %+v", closing[Min5])
+	}
+	if b.Current("rb2305", Min1) != nil || b.Current("rb2305", Min5) != nil {
+		t.Fatal("收盘后不应有未完成的 bar")
+	}
+}
+
+func TestBreak(t *testing.T) {
+	b := NewBuilder(Min15, Hour1)
+	done := collect(b, ticks(
+		"10:14:00", 3000.0, 10,
+		"10:15:00", 3001.0, 12, // 休盘: 完成 10:00 的 15 分钟 bar, 1 小时 bar 跨越休盘不完成
+		"10:30:00", 3002.0, 15,
+	))
+	if len(done[1]) != 1 || done[1][0].Period != Min15 || done[1][0].Time != "10:00:00" || done[1][0].Close != 3001 {
+		t.Fatalf("休盘应完成 10:00 的 15 分钟 bar: %v", done[1])
+	}
+	if len(done[2]) != 0 {
+		t.Fatalf("休盘后开盘不应再完成 bar: %v", done[2])
+	}
+	if bar := b.Current("rb2305", Hour1); bar == nil || bar.Time != "10:00:00" || bar.Volume != 5 || bar.Close != 3002 {
+		t.Fatalf("10:00 的 1 小时 bar: %+v", bar)
+	}
+	if bar := b.Current("rb2305", Min15); bar == nil || bar.Time != "10:30:00" {
+		t.Fatalf("10:30 的 15 分钟 bar: %+v", bar)
+	}
+}
+
+func TestMidnight(t *testing.T) {
+	b := NewBuilder(Min1, Day)
+	var bars []*Bar
+	b.RegOnBar(func(bar *Bar) { bars = append(bars, bar) })
+	for _, tick := range ticks(
+		"23:59:30", 500.0, 1,
+		"00:00:10", 501.0, 3, // 跨午夜: 完成 23:59
+		"02:30:00", 502.0, 4, // 收盘
+		"14:59:59", 503.0, 6,
+		"15:00:00", 504.0, 7, // 日盘收盘: 完成日线
+	) {
+		tick.InstrumentID = "au2306"
+		b.Update(tick)
+	}
+	var day *Bar
+	var times []string
+	for _, bar := range bars {
+		if bar.Period == Day {
+			day = bar
+		} else {
+			times = append(times, bar.ActionDay+" "+bar.Time)
+		}
+	}
+	want := []string{"20230104 23:59:00", "20230105 00:00:00", "20230105 02:29:00", "20230105 14:59:00"}
+	if len(times) != len(want) {
+		t.Fatalf("1 分钟 bar: %v", times)
+	}
+	for i := range want {
+		if times[i] != want[i] {
+			t.Fatalf("第 %d 根 1 分钟 bar 为 %s, 应为 %s", i+1, times[i], want[i])
+		}
+	}
+	if day == nil || day.Time != "21:00:00" || day.Open != 500 || day.Close != 504 || day.High != 504 || day.Low != 500 {
+		t.Fatalf("日线: %+v", day)
+	}
+}
+
+func TestTradingDaySwitch(t *testing.T) {
+	b := NewBuilder(Day)
+	done := collect(b, ticks("14:30:00", 3000.0, 100))
+	if len(done[0]) != 0 {
+		t.Fatal("盘中不应完成日线")
+	}
+	next := ticks("21:00:00", 3010.0, 5)[0]
+	next.TradingDay = "20230106"
+	done = collect(b, []*goctp.TickField{next})
+	if len(done[0]) != 1 || done[0][0].TradingDay != "20230105" || done[0][0].Volume != 0 {
+		t.Fatalf("交易日切换应完成前一交易日的日线: %v", done[0])
+	}
+	if bar := b.Current("rb2305", Day); bar == nil || bar.TradingDay != "20230106" || bar.Volume != 5 {
+		t.Fatalf("新交易日的日线: %+v", bar)
+	}
+}
@@ -0,0 +1,100 @@
+package bar
+
+import (
+	"sync"
+)
+
+// Hours 交易时段, 每段为 [开始, 结束) 的 "HH:MM", 按交易日内的先后顺序(夜盘在前), 跨午夜的夜盘如 {"21:00", "02:30"}
+type Hours [][2]string
+
+var (
+	hoursDay = Hours{{"09:00", "10:15"}, {"10:30", "11:30"}, {"13:30", "15:00"}}
+	// 夜盘至 23:00
+	hoursNight2300 = append(Hours{{"21:00", "23:00"}}, hoursDay...)
+	// 夜盘至 01:00
+	hoursNight0100 = append(Hours{{"21:00", "01:00"}}, hoursDay...)
+	// 夜盘至 02:30
+	hoursNight0230 = append(Hours{{"21:00", "02:30"}}, hoursDay...)
+	// 股指
+	hoursIndex = Hours{{"09:30", "11:30"}, {"13:00", "15:00"}}
+	// 国债
+	hoursBond = Hours{{"09:30", "11:30"}, {"13:00", "15:15"}}
+)
+
+var (
+	hoursMu      sync.RWMutex
+	productHours = map[string]Hours{}
+)
+
+func init() {
+	reg := func(hours Hours, products ...string) {
+		for _, p := range products {
+			productHours[p] = hours
+		}
+	}
+	// 上期/能源
+	reg(hoursNight2300, "rb", "hc", "bu", "ru", "fu", "sp", "br", "lu", "nr")
+	reg(hoursNight0100, "cu", "al", "zn", "pb", "ni", "sn", "ss", "ao", "bc")
+	reg(hoursNight0230, "au", "ag", "sc")
+	reg(hoursDay, "wr", "ec")
+	// 大商所
+	reg(hoursNight2300, "a", "b", "m", "y", "p", "j", "jm", "i", "c", "cs", "l", "v", "pp", "eg", "rr", "eb", "pg")
+	reg(hoursDay, "jd", "lh", "fb", "bb")
+	// 郑商所
+	reg(hoursNight2300, "SR", "CF", "CY", "TA", "MA", "FG", "RM", "OI", "ZC", "SA", "PF", "SH", "PX")
+	reg(hoursDay, "AP", "CJ", "JR", "LR", "PM", "RI", "RS", "SF", "SM", "UR", "WH", "PK")
+	// 广期所
+	reg(hoursDay, "si", "lc")
+	// 中金所
+	reg(hoursIndex, "IF", "IC", "IH", "IM", "IO", "MO", "HO")
+	reg(hoursBond, "T", "TF", "TS", "TL")
+}
+
+// RegProductHours 注册(覆盖)品种的交易时段
+func RegProductHours(product string, hours Hours) {
+	hoursMu.Lock()
+	defer hoursMu.Unlock()
+	productHours[product] = hours
+}
+
+// ProductHours 合约的交易时段; 未注册的品种按 夜盘(至 02:30)+日盘 处理
+func ProductHours(instrumentID string) Hours {
+	product := instrumentID
+	for i, c := range instrumentID {
+		if c < 'A' || c > 'z' || (c > 'Z' && c < 'a') {
+			product = instrumentID[:i]
+			break
+		}
+	}
+	hoursMu.RLock()
+	defer hoursMu.RUnlock()
+	if hours, ok := productHours[product]; ok {
+		return hours
+	}
+	return hoursNight0230
+}
+
+// session 交易时段(交易日内的分钟数, 夜盘为负)
+type session struct {
+	begin, end int
+}
+
+func (h Hours) sessions() []session {
+	ss := make([]session, 0, len(h))
+	for _, s := range h {
+		ss = append(ss, session{minuteOf(s[0]), minuteOf(s[1])})
+	}
+	return ss
+}
+
+// minuteOf "HH:MM[:SS]" 转为交易日内的分钟数: 18 点之后为前一晚的夜盘, 记为负数(跨午夜的时段自然连续)
+func minuteOf(s string) int {
+	if len(s) < 5 {
+		return 0
+	}
+	m := int(s[0]-'0')*600 + int(s[1]-'0')*60 + int(s[3]-'0')*10 + int(s[4]-'0')
+	if m >= 18*60 {
+		m -= 24 * 60
+	}
+	return m
+}
@@ -12,6 +12,15 @@ type OnRspUserLoginType func(loginField *RspUserLoginField, info *RspInfoField)
 // 行情
 type OnTickType func(tick *TickField)
 
+// 行情-订阅响应
+type OnRspSubMarketDataType func(instrument string, info *RspInfoField)
+
+// 行情-退订响应
+type OnRspUnSubMarketDataType func(instrument string, info *RspInfoField)
+
+// 行情-断线恢复(自动重新登录并重新订阅)
+type OnRecoveredType func(instruments []string, info *RspInfoField)
+
 // 交易-委托响应
 type OnRtnOrderType func(field *OrderField)
 
@@ -32,3 +41,45 @@ type OnRtnFromBankToFutureByFuture func(field *TransferField)
 
 // 银转-期货->银行
 type OnRtnFromFutureToBankByFuture func(field *TransferField)
+
+// 交易-执行宣告响应
+type OnRtnExecOrderType func(field *ExecOrderField)
+
+// 交易-错误执行宣告
+type OnRtnErrExecOrderType func(field *ExecOrderField, info *RspInfoField)
+
+// 交易-报价响应
+type OnRtnQuoteType func(field *QuoteField)
+
+// 交易-错误报价
+type OnRtnErrQuoteType func(field *QuoteField, info *RspInfoField)
+
+// 交易-错误询价
+type OnRtnErrForQuoteType func(instrument string, info *RspInfoField)
+
+// 行情/交易-询价通知
+type OnRtnForQuoteType func(field *ForQuoteField)
+
+// 交易-预埋单响应(接受/触发/删除)
+type OnRtnParkedOrderType func(field *ParkedOrderField)
+
+// 交易-错误预埋单
+type OnRtnErrParkedOrderType func(field *ParkedOrderField, info *RspInfoField)
+
+// 交易-组合指令响应
+type OnRtnCombActionType func(field *CombActionField)
+
+// 交易-错误组合指令
+type OnRtnErrCombActionType func(field *CombActionField, info *RspInfoField)
+
+// 交易-期权自对冲响应
+type OnRtnOptionSelfCloseType func(field *OptionSelfCloseField)
+
+// 交易-错误期权自对冲
+type OnRtnErrOptionSelfCloseType func(field *OptionSelfCloseField, info *RspInfoField)
+
+// 交易-改单响应: 原生改单时 newOrderID 与 orderID 相同, 撤单重发时为新委托; 失败时 info.ErrorID != 0
+type OnRtnOrderModifyType func(orderID, newOrderID string, info *RspInfoField)
+
+// 交易-本地条件单响应(触发/撤销)
+type OnRtnCondOrderType func(field *CondOrderField)
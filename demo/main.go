@@ -76,16 +76,16 @@ func testQuote() {
 	})
 	q.RegOnRspUserLogin(func(login *goctp.RspUserLoginField, info *goctp.RspInfoField) {
 		fmt.Printf("quote login: %+v\n", info)
-		q.ReqSubMarketData("rb2305")
+		q.ReqSubscript("rb2305")
+	})
+	q.RegOnRecovered(func(instruments []string, info *goctp.RspInfoField) {
+		fmt.Printf("quote recovered: %v %+v\n", instruments, info)
 	})
 	q.RegOnTick(func(tick *goctp.TickField) {
 		fmt.Printf("%+v\n", tick)
 	})
 	q.RegOnFrontDisConnected(func(reason int) {
-		fmt.Println("quote disconected ", reason)
-		if reason != 0 {
-			releaseQuote()
-		}
+		fmt.Println("quote disconected ", reason) // 自动重连, 重连后恢复登录与订阅
 	})
 	fmt.Println("connecting to quote " + quoteFront)
 	q.ReqConnect(quoteFront)
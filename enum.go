@@ -142,6 +142,26 @@ const (
 	OrderStatusTouched OrderStatusType = 'c'
 )
 
+// 报单提交状态类型
+type OrderSubmitStatusType byte
+
+const (
+	// 已经提交
+	OrderSubmitStatusInsertSubmitted OrderSubmitStatusType = '0'
+	// 撤单已经提交
+	OrderSubmitStatusCancelSubmitted OrderSubmitStatusType = '1'
+	// 修改已经提交
+	OrderSubmitStatusModifySubmitted OrderSubmitStatusType = '2'
+	// 已经接受
+	OrderSubmitStatusAccepted OrderSubmitStatusType = '3'
+	// 报单已经被拒绝
+	OrderSubmitStatusInsertRejected OrderSubmitStatusType = '4'
+	// 撤单已经被拒绝
+	OrderSubmitStatusCancelRejected OrderSubmitStatusType = '5'
+	// 改单已经被拒绝
+	OrderSubmitStatusModifyRejected OrderSubmitStatusType = '6'
+)
+
 // 合约交易状态类型
 type InstrumentStatusType byte
 
@@ -161,3 +181,209 @@ const (
 	// 收盘
 	InstrumentStatusClosed InstrumentStatusType = '6'
 )
+
+// 订阅状态类型
+type SubscribeStatusType byte
+
+const (
+	// 已请求未响应
+	SubscribeStatusPending SubscribeStatusType = '0'
+	// 订阅成功
+	SubscribeStatusActive SubscribeStatusType = '1'
+	// 订阅失败
+	SubscribeStatusFailed SubscribeStatusType = '2'
+)
+
+// 执行类型
+type ExecActionType byte
+
+const (
+	// 执行(行权)
+	ExecActionExec ExecActionType = '1'
+	// 放弃(放弃行权)
+	ExecActionAbandon ExecActionType = '2'
+)
+
+// 执行结果类型
+type ExecResultType byte
+
+const (
+	// 没有执行(已提交, 等待交易所处理)
+	ExecResultNoExec ExecResultType = 'n'
+	// 已经取消
+	ExecResultCanceled ExecResultType = 'c'
+	// 执行成功
+	ExecResultOK ExecResultType = '0'
+	// 期权持仓不够
+	ExecResultNoPosition ExecResultType = '1'
+	// 资金不够
+	ExecResultNoDeposit ExecResultType = '2'
+	// 会员不存在
+	ExecResultNoParticipant ExecResultType = '3'
+	// 客户不存在
+	ExecResultNoClient ExecResultType = '4'
+	// 合约不存在
+	ExecResultNoInstrument ExecResultType = '6'
+	// 没有执行权限
+	ExecResultNoRight ExecResultType = '7'
+	// 不合理的数量
+	ExecResultInvalidVolume ExecResultType = '8'
+	// 没有足够的历史成交
+	ExecResultNoEnoughHistoryTrade ExecResultType = '9'
+	// 未知
+	ExecResultUnknown ExecResultType = 'a'
+)
+
+// 预埋单状态类型
+type ParkedOrderStatusType byte
+
+const (
+	// 已请求未响应(本地)
+	ParkedOrderStatusPending ParkedOrderStatusType = '0'
+	// 未发送(已被接受, 等待触发)
+	ParkedOrderStatusNotSend ParkedOrderStatusType = '1'
+	// 已发送(已触发)
+	ParkedOrderStatusSend ParkedOrderStatusType = '2'
+	// 已删除
+	ParkedOrderStatusDeleted ParkedOrderStatusType = '3'
+	// 被拒绝(本地)
+	ParkedOrderStatusRejected ParkedOrderStatusType = '4'
+)
+
+// 组合指令方向类型
+type CombDirectionType byte
+
+const (
+	// 申请组合
+	CombDirectionComb CombDirectionType = '0'
+	// 申请拆分
+	CombDirectionUnComb CombDirectionType = '1'
+	// 操作员删组合单
+	CombDirectionDelComb CombDirectionType = '2'
+)
+
+// 组合指令状态类型
+type CombActionStatusType byte
+
+const (
+	// 已经提交
+	CombActionStatusSubmitted CombActionStatusType = 'a'
+	// 已经接受
+	CombActionStatusAccepted CombActionStatusType = 'b'
+	// 已经被拒绝
+	CombActionStatusRejected CombActionStatusType = 'c'
+)
+
+// 期权自对冲标识类型
+type OptSelfCloseFlagType byte
+
+const (
+	// 自对冲期权仓位
+	OptSelfCloseFlagCloseSelfOptionPosition OptSelfCloseFlagType = '1'
+	// 保留期权仓位
+	OptSelfCloseFlagReserveOptionPosition OptSelfCloseFlagType = '2'
+	// 自对冲卖方履约后的期货仓位
+	OptSelfCloseFlagSellCloseSelfFuturePosition OptSelfCloseFlagType = '3'
+	// 保留卖方履约后的期货仓位
+	OptSelfCloseFlagReserveFuturePosition OptSelfCloseFlagType = '4'
+)
+
+// 报单价格条件类型
+type OrderPriceTypeType byte
+
+const (
+	// 任意价
+	OrderPriceTypeAnyPrice OrderPriceTypeType = '1'
+	// 限价
+	OrderPriceTypeLimitPrice OrderPriceTypeType = '2'
+	// 最优价
+	OrderPriceTypeBestPrice OrderPriceTypeType = '3'
+	// 最新价
+	OrderPriceTypeLastPrice OrderPriceTypeType = '4'
+	// 卖一价
+	OrderPriceTypeAskPrice1 OrderPriceTypeType = '8'
+	// 买一价
+	OrderPriceTypeBidPrice1 OrderPriceTypeType = 'C'
+	// 五档价
+	OrderPriceTypeFiveLevelPrice OrderPriceTypeType = 'G'
+)
+
+// 有效期类型类型
+type TimeConditionType byte
+
+const (
+	// 立即完成，否则撤销
+	TimeConditionIOC TimeConditionType = '1'
+	// 本节有效
+	TimeConditionGFS TimeConditionType = '2'
+	// 当日有效
+	TimeConditionGFD TimeConditionType = '3'
+	// 指定日期前有效
+	TimeConditionGTD TimeConditionType = '4'
+	// 撤销前有效
+	TimeConditionGTC TimeConditionType = '5'
+	// 集合竞价有效
+	TimeConditionGFA TimeConditionType = '6'
+)
+
+// 成交量类型类型
+type VolumeConditionType byte
+
+const (
+	// 任何数量
+	VolumeConditionAV VolumeConditionType = '1'
+	// 最小数量
+	VolumeConditionMV VolumeConditionType = '2'
+	// 全部数量
+	VolumeConditionCV VolumeConditionType = '3'
+)
+
+// 触发条件类型
+type ContingentConditionType byte
+
+const (
+	// 立即
+	ContingentConditionImmediately ContingentConditionType = '1'
+	// 止损
+	ContingentConditionTouch ContingentConditionType = '2'
+	// 止赢
+	ContingentConditionTouchProfit ContingentConditionType = '3'
+	// 预埋单
+	ContingentConditionParkedOrder ContingentConditionType = '4'
+	// 最新价大于条件价
+	ContingentConditionLastPriceGreaterThanStopPrice ContingentConditionType = '5'
+	// 最新价大于等于条件价
+	ContingentConditionLastPriceGreaterEqualStopPrice ContingentConditionType = '6'
+	// 最新价小于条件价
+	ContingentConditionLastPriceLesserThanStopPrice ContingentConditionType = '7'
+	// 最新价小于等于条件价
+	ContingentConditionLastPriceLesserEqualStopPrice ContingentConditionType = '8'
+	// 卖一价大于条件价
+	ContingentConditionAskPriceGreaterThanStopPrice ContingentConditionType = '9'
+	// 卖一价大于等于条件价
+	ContingentConditionAskPriceGreaterEqualStopPrice ContingentConditionType = 'A'
+	// 卖一价小于条件价
+	ContingentConditionAskPriceLesserThanStopPrice ContingentConditionType = 'B'
+	// 卖一价小于等于条件价
+	ContingentConditionAskPriceLesserEqualStopPrice ContingentConditionType = 'C'
+	// 买一价大于条件价
+	ContingentConditionBidPriceGreaterThanStopPrice ContingentConditionType = 'D'
+	// 买一价大于等于条件价
+	ContingentConditionBidPriceGreaterEqualStopPrice ContingentConditionType = 'E'
+	// 买一价小于条件价
+	ContingentConditionBidPriceLesserThanStopPrice ContingentConditionType = 'F'
+	// 买一价小于等于条件价
+	ContingentConditionBidPriceLesserEqualStopPrice ContingentConditionType = 'H'
+)
+
+// 本地条件单状态类型
+type CondOrderStatusType byte
+
+const (
+	// 未触发
+	CondOrderStatusPending CondOrderStatusType = '0'
+	// 已触发
+	CondOrderStatusTriggered CondOrderStatusType = '1'
+	// 已撤销
+	CondOrderStatusCanceled CondOrderStatusType = '2'
+)
@@ -1,6 +1,7 @@
 package goctp
 
 import (
+	"math"
 	"strings"
 
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -10,3 +11,8 @@ func Bytes2String(t []byte) string {
 	msg, _ := simplifiedchinese.GB18030.NewDecoder().Bytes(t)
 	return strings.Trim(string(msg), "\u0000")
 }
+
+// ValidPrice 有效价格: 过滤 0 与 CTP 无报价时的无效值(DBL_MAX)
+func ValidPrice(price float64) bool {
+	return price > 0 && price < math.MaxFloat64
+}
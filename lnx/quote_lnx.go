@@ -153,6 +153,30 @@ func NewQuote() *Quote {
 		}
 		C.qSubscribeMarketData(q.api, (**C.char)(unsafe.Pointer(&ppInstrumentID[0])), C.int(len(instrument)))
 	}
+	q.HFQuote.ReqUnSubMarketData = func(instrument ...string) {
+		ppInstrumentID := make([]*C.char, len(instrument))
+		for i := 0; i < len(instrument); i++ {
+			ppInstrumentID[i] = C.CString(instrument[i])
+			defer C.free(unsafe.Pointer(ppInstrumentID[i]))
+		}
+		C.qUnSubscribeMarketData(q.api, (**C.char)(unsafe.Pointer(&ppInstrumentID[0])), C.int(len(instrument)))
+	}
+	q.HFQuote.ReqSubForQuote = func(instrument ...string) {
+		ppInstrumentID := make([]*C.char, len(instrument))
+		for i := 0; i < len(instrument); i++ {
+			ppInstrumentID[i] = C.CString(instrument[i])
+			defer C.free(unsafe.Pointer(ppInstrumentID[i]))
+		}
+		C.qSubscribeForQuoteRsp(q.api, (**C.char)(unsafe.Pointer(&ppInstrumentID[0])), C.int(len(instrument)))
+	}
+	q.HFQuote.ReqUnSubForQuote = func(instrument ...string) {
+		ppInstrumentID := make([]*C.char, len(instrument))
+		for i := 0; i < len(instrument); i++ {
+			ppInstrumentID[i] = C.CString(instrument[i])
+			defer C.free(unsafe.Pointer(ppInstrumentID[i]))
+		}
+		C.qUnSubscribeForQuoteRsp(q.api, (**C.char)(unsafe.Pointer(&ppInstrumentID[0])), C.int(len(instrument)))
+	}
 	 
 	// HFQuote 响应  手动添加即可增加新功能
 	q._RtnDepthMarketData = func(f *ctp.CThostFtdcDepthMarketDataField) {
@@ -161,6 +185,15 @@ func NewQuote() *Quote {
 	q._RspUserLogin = func(f *ctp.CThostFtdcRspUserLoginField, i *ctp.CThostFtdcRspInfoField, n int, b bool) {
 		q.HFQuote.RspUserLogin(f, i)
 	}
+	q._RspSubMarketData = func(f *ctp.CThostFtdcSpecificInstrumentField, i *ctp.CThostFtdcRspInfoField, n int, b bool) {
+		q.HFQuote.RspSubMarketData(f, i)
+	}
+	q._RspUnSubMarketData = func(f *ctp.CThostFtdcSpecificInstrumentField, i *ctp.CThostFtdcRspInfoField, n int, b bool) {
+		q.HFQuote.RspUnSubMarketData(f, i)
+	}
+	q._RtnForQuoteRsp = func(f *ctp.CThostFtdcForQuoteRspField) {
+		q.HFQuote.RtnForQuoteRsp(f)
+	}
 	q._FrontConnected = func() {
 		q.HFQuote.FrontConnected()
 	}
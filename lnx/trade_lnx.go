@@ -946,8 +946,167 @@ func NewTrade() *Trade {
 	t.HFTrade.ReqQryTrade = func(f *ctp.CThostFtdcQryTradeField, i int) {
 		C.tReqQryTrade(t.api, (*C.struct_CThostFtdcQryTradeField)(unsafe.Pointer(f)), C.int(i))
 	}
+	t.HFTrade.ReqExecOrder = func(f *ctp.CThostFtdcInputExecOrderField, i int) {
+		C.tReqExecOrderInsert(t.api, (*C.struct_CThostFtdcInputExecOrderField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqExecAction = func(f *ctp.CThostFtdcInputExecOrderActionField, i int) {
+		C.tReqExecOrderAction(t.api, (*C.struct_CThostFtdcInputExecOrderActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqQuote = func(f *ctp.CThostFtdcInputQuoteField, i int) {
+		C.tReqQuoteInsert(t.api, (*C.struct_CThostFtdcInputQuoteField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqActionQuote = func(f *ctp.CThostFtdcInputQuoteActionField, i int) {
+		C.tReqQuoteAction(t.api, (*C.struct_CThostFtdcInputQuoteActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqForQuote = func(f *ctp.CThostFtdcInputForQuoteField, i int) {
+		C.tReqForQuoteInsert(t.api, (*C.struct_CThostFtdcInputForQuoteField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqParkedOrder = func(f *ctp.CThostFtdcParkedOrderField, i int) {
+		C.tReqParkedOrderInsert(t.api, (*C.struct_CThostFtdcParkedOrderField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqParkedAction = func(f *ctp.CThostFtdcParkedOrderActionField, i int) {
+		C.tReqParkedOrderAction(t.api, (*C.struct_CThostFtdcParkedOrderActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqRemoveParked = func(f *ctp.CThostFtdcRemoveParkedOrderField, i int) {
+		C.tReqRemoveParkedOrder(t.api, (*C.struct_CThostFtdcRemoveParkedOrderField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqRemoveParkedAction = func(f *ctp.CThostFtdcRemoveParkedOrderActionField, i int) {
+		C.tReqRemoveParkedOrderAction(t.api, (*C.struct_CThostFtdcRemoveParkedOrderActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqQryParked = func(f *ctp.CThostFtdcQryParkedOrderField, i int) {
+		C.tReqQryParkedOrder(t.api, (*C.struct_CThostFtdcQryParkedOrderField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqQryParkedAction = func(f *ctp.CThostFtdcQryParkedOrderActionField, i int) {
+		C.tReqQryParkedOrderAction(t.api, (*C.struct_CThostFtdcQryParkedOrderActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqCombAction = func(f *ctp.CThostFtdcInputCombActionField, i int) {
+		C.tReqCombActionInsert(t.api, (*C.struct_CThostFtdcInputCombActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqQryComb = func(f *ctp.CThostFtdcQryCombActionField, i int) {
+		C.tReqQryCombAction(t.api, (*C.struct_CThostFtdcQryCombActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqOptionSelfClose = func(f *ctp.CThostFtdcInputOptionSelfCloseField, i int) {
+		C.tReqOptionSelfCloseInsert(t.api, (*C.struct_CThostFtdcInputOptionSelfCloseField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqActionOptionSelfClose = func(f *ctp.CThostFtdcInputOptionSelfCloseActionField, i int) {
+		C.tReqOptionSelfCloseAction(t.api, (*C.struct_CThostFtdcInputOptionSelfCloseActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqQrySelfClose = func(f *ctp.CThostFtdcQryOptionSelfCloseField, i int) {
+		C.tReqQryOptionSelfClose(t.api, (*C.struct_CThostFtdcQryOptionSelfCloseField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqBatchAction = func(f *ctp.CThostFtdcInputBatchOrderActionField, i int) {
+		C.tReqBatchOrderAction(t.api, (*C.struct_CThostFtdcInputBatchOrderActionField)(unsafe.Pointer(f)), C.int(i))
+	}
+	t.HFTrade.ReqQryDepth = func(f *ctp.CThostFtdcQryDepthMarketDataField, i int) {
+		C.tReqQryDepthMarketData(t.api, (*C.struct_CThostFtdcQryDepthMarketDataField)(unsafe.Pointer(f)), C.int(i))
+	}
 	
 	// HFTrade 响应 手动添加即可增加新功能
+	t._RtnExecOrder = func(pExecOrder *ctp.CThostFtdcExecOrderField) {
+		t.HFTrade.RtnExecOrder(pExecOrder)
+	}
+	t._ErrRtnExecOrderInsert = func(pInputExecOrder *ctp.CThostFtdcInputExecOrderField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnExecOrderInsert(pInputExecOrder, pRspInfo)
+	}
+	t._ErrRtnExecOrderAction = func(pExecOrderAction *ctp.CThostFtdcExecOrderActionField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnExecOrderAction(pExecOrderAction, pRspInfo)
+	}
+	t._RtnQuote = func(pQuote *ctp.CThostFtdcQuoteField) {
+		t.HFTrade.RtnQuote(pQuote)
+	}
+	t._ErrRtnQuoteInsert = func(pInputQuote *ctp.CThostFtdcInputQuoteField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnQuoteInsert(pInputQuote, pRspInfo)
+	}
+	t._ErrRtnQuoteAction = func(pQuoteAction *ctp.CThostFtdcQuoteActionField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnQuoteAction(pQuoteAction, pRspInfo)
+	}
+	t._ErrRtnForQuoteInsert = func(pInputForQuote *ctp.CThostFtdcInputForQuoteField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnForQuoteInsert(pInputForQuote, pRspInfo)
+	}
+	t._RtnForQuoteRsp = func(pForQuoteRsp *ctp.CThostFtdcForQuoteRspField) {
+		t.HFTrade.RtnForQuoteRsp(pForQuoteRsp)
+	}
+	t._RspParkedOrderInsert = func(pParkedOrder *ctp.CThostFtdcParkedOrderField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pParkedOrder == nil { // 处理空指针
+			pParkedOrder = &ctp.CThostFtdcParkedOrderField{}
+		}
+		t.HFTrade.RspParkedOrderInsert(pParkedOrder, pRspInfo)
+	}
+	t._RspParkedOrderAction = func(pParkedOrderAction *ctp.CThostFtdcParkedOrderActionField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pParkedOrderAction == nil { // 处理空指针
+			pParkedOrderAction = &ctp.CThostFtdcParkedOrderActionField{}
+		}
+		t.HFTrade.RspParkedOrderAction(pParkedOrderAction, pRspInfo)
+	}
+	t._RspRemoveParkedOrder = func(pRemoveParkedOrder *ctp.CThostFtdcRemoveParkedOrderField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pRemoveParkedOrder == nil { // 处理空指针
+			pRemoveParkedOrder = &ctp.CThostFtdcRemoveParkedOrderField{}
+		}
+		t.HFTrade.RspRemoveParkedOrder(pRemoveParkedOrder, pRspInfo)
+	}
+	t._RspRemoveParkedOrderAction = func(pRemoveParkedOrderAction *ctp.CThostFtdcRemoveParkedOrderActionField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pRemoveParkedOrderAction == nil { // 处理空指针
+			pRemoveParkedOrderAction = &ctp.CThostFtdcRemoveParkedOrderActionField{}
+		}
+		t.HFTrade.RspRemoveParkedOrderAction(pRemoveParkedOrderAction, pRspInfo)
+	}
+	t._RspQryParkedOrder = func(pParkedOrder *ctp.CThostFtdcParkedOrderField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pParkedOrder == nil { // 处理空指针
+			pParkedOrder = &ctp.CThostFtdcParkedOrderField{}
+		}
+		t.HFTrade.RspQryParkedOrder(pParkedOrder)
+	}
+	t._RspQryParkedOrderAction = func(pParkedOrderAction *ctp.CThostFtdcParkedOrderActionField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pParkedOrderAction == nil { // 处理空指针
+			pParkedOrderAction = &ctp.CThostFtdcParkedOrderActionField{}
+		}
+		t.HFTrade.RspQryParkedOrderAction(pParkedOrderAction)
+	}
+	t._RtnCombAction = func(pCombAction *ctp.CThostFtdcCombActionField) {
+		t.HFTrade.RtnCombAction(pCombAction)
+	}
+	t._ErrRtnCombActionInsert = func(pInputCombAction *ctp.CThostFtdcInputCombActionField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnCombActionInsert(pInputCombAction, pRspInfo)
+	}
+	t._RspQryCombAction = func(pCombAction *ctp.CThostFtdcCombActionField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pCombAction == nil { // 处理空指针
+			pCombAction = &ctp.CThostFtdcCombActionField{}
+		}
+		t.HFTrade.RspQryCombAction(pCombAction)
+	}
+	t._RtnOptionSelfClose = func(pOptionSelfClose *ctp.CThostFtdcOptionSelfCloseField) {
+		t.HFTrade.RtnOptionSelfClose(pOptionSelfClose)
+	}
+	t._ErrRtnOptionSelfCloseInsert = func(pInputOptionSelfClose *ctp.CThostFtdcInputOptionSelfCloseField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnOptionSelfCloseInsert(pInputOptionSelfClose, pRspInfo)
+	}
+	t._ErrRtnOptionSelfCloseAction = func(pOptionSelfCloseAction *ctp.CThostFtdcOptionSelfCloseActionField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnOptionSelfCloseAction(pOptionSelfCloseAction, pRspInfo)
+	}
+	t._RspQryOptionSelfClose = func(pOptionSelfClose *ctp.CThostFtdcOptionSelfCloseField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pOptionSelfClose == nil { // 处理空指针
+			pOptionSelfClose = &ctp.CThostFtdcOptionSelfCloseField{}
+		}
+		t.HFTrade.RspQryOptionSelfClose(pOptionSelfClose)
+	}
+	t._RspBatchOrderAction = func(pInputBatchOrderAction *ctp.CThostFtdcInputBatchOrderActionField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pInputBatchOrderAction == nil { // 处理空指针
+			pInputBatchOrderAction = &ctp.CThostFtdcInputBatchOrderActionField{}
+		}
+		t.HFTrade.RspBatchOrderAction(pInputBatchOrderAction, pRspInfo)
+	}
+	t._RspQryDepthMarketData = func(pDepthMarketData *ctp.CThostFtdcDepthMarketDataField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pDepthMarketData == nil { // 处理空指针
+			pDepthMarketData = &ctp.CThostFtdcDepthMarketDataField{}
+		}
+		if pRspInfo == nil {
+			pRspInfo = &ctp.CThostFtdcRspInfoField{}
+		}
+		t.HFTrade.RspQryDepthMarketData(pDepthMarketData, pRspInfo, nRequestID, bIsLast)
+	}
+	t._ErrRtnBatchOrderAction = func(pBatchOrderAction *ctp.CThostFtdcBatchOrderActionField, pRspInfo *ctp.CThostFtdcRspInfoField) {
+		t.HFTrade.ErrRtnBatchOrderAction(pBatchOrderAction, pRspInfo)
+	}
 	t._RtnFromFutureToBankByFuture = func(pRspTransfer *ctp.CThostFtdcRspTransferField) {
 		t.HFTrade.RtnFromFutureToBankByFuture(pRspTransfer)
 	}
@@ -960,6 +1119,11 @@ func NewTrade() *Trade {
 	t._ErrRtnOrderInsert = func(pInputOrder *ctp.CThostFtdcInputOrderField, pRspInfo *ctp.CThostFtdcRspInfoField) {
 		t.HFTrade.ErrRtnOrderInsert(pInputOrder, pRspInfo)
 	}
+	t._RspOrderInsert = func(pInputOrder *ctp.CThostFtdcInputOrderField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
+		if pInputOrder != nil && pRspInfo != nil {
+			t.HFTrade.RspOrderInsert(pInputOrder, pRspInfo)
+		}
+	}
 	t._RtnTrade = func(pTrade *ctp.CThostFtdcTradeField) {
 		t.HFTrade.RtnTrade(pTrade)
 	}
@@ -994,19 +1158,19 @@ func NewTrade() *Trade {
 		t.HFTrade.RspQryInvestor(pInvestor, bIsLast)
 	}
 	t._RspQryInstrument = func(pInstrument *ctp.CThostFtdcInstrumentField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
-		t.HFTrade.RspQryInstrument(pInstrument, bIsLast)
+		t.HFTrade.RspQryInstrument(pInstrument, pRspInfo, nRequestID, bIsLast)
 	}
 	t._RspQryClassifiedInstrument = func(pInstrument *ctp.CThostFtdcInstrumentField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
-		t.HFTrade.RspQryInstrument(pInstrument, bIsLast)
+		t.HFTrade.RspQryInstrument(pInstrument, pRspInfo, nRequestID, bIsLast)
 	}
 	t._RspSettlementInfoConfirm = func(pSettlementInfoConfirm *ctp.CThostFtdcSettlementInfoConfirmField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
 		t.HFTrade.RspSettlementInfoConfirm()
 	}
 	t._RspUserLogin = func(pRspUserLogin *ctp.CThostFtdcRspUserLoginField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
-		t.HFTrade.RspUserLogin(pRspUserLogin, pRspInfo)
+		t.HFTrade.RspUserLogin(pRspUserLogin, pRspInfo, nRequestID)
 	}
 	t._RspAuthenticate = func(pRspAuthenticateField *ctp.CThostFtdcRspAuthenticateField, pRspInfo *ctp.CThostFtdcRspInfoField, nRequestID int, bIsLast bool) {
-		t.HFTrade.RspAuthenticate(pRspInfo)
+		t.HFTrade.RspAuthenticate(pRspInfo, nRequestID)
 	}
 	t._FrontConnected = func() {
 		t.HFTrade.FrontConnected()
@@ -1,7 +1,9 @@
 package goctp
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"sync"
 
 	"gitee.com/haifengat/goctp/ctpdefine"
@@ -12,23 +14,37 @@ type HFQuote struct {
 	IsLogin    bool
 	InvestorID string
 	BrokerID   string
+	passWord   string
 
-	ReqConnect       ReqConnectType
-	ReleaseAPI       ReleaseAPIType
-	ReqUserLogin     ReqUserLoginType
-	ReqSubMarketData ReqSubscriptType
+	ReqConnect         ReqConnectType
+	ReleaseAPI         ReleaseAPIType
+	ReqUserLogin       ReqUserLoginType
+	ReqSubMarketData   ReqSubscriptType
+	ReqUnSubMarketData ReqSubscriptType
+	ReqSubForQuote     ReqSubscriptType // 可选: 订阅询价
+	ReqUnSubForQuote   ReqSubscriptType // 可选: 退订询价
 
-	onFrontConnected    OnFrontConnectedType
-	onFrontDisConnected OnFrontDisConnectedType
-	onRspUserLogin      OnRspUserLoginType
-	onTick              OnTickType
+	onFrontConnected     OnFrontConnectedType
+	onFrontDisConnected  OnFrontDisConnectedType
+	onRspUserLogin       OnRspUserLoginType
+	onTick               OnTickType
+	onRspSubMarketData   OnRspSubMarketDataType
+	onRspUnSubMarketData OnRspUnSubMarketDataType
+	onRecovered          OnRecoveredType
+	onRtnForQuote        OnRtnForQuoteType
 
-	Ticks sync.Map // 合约:TickField
+	Ticks      sync.Map // 合约:TickField
+	subscribed sync.Map // 合约:SubscribeField 订阅状态, 断线重连后重新订阅
+	forQuotes  sync.Map // 合约:struct{} 订阅的询价, 断线重连后重新订阅
+	recovering bool     // 登录后断线, 重连时自动恢复
 }
 
 type ReqSubscriptType func(...string)
 
 func (q *HFQuote) Init() {
+	if q.ReqConnect == nil || q.ReleaseAPI == nil || q.ReqUserLogin == nil || q.ReqSubMarketData == nil || q.ReqUnSubMarketData == nil {
+		panic("缺少继承函数")
+	}
 	// 执行目录下创建 log目录
 	_, err := os.Stat("log")
 	if err != nil {
@@ -38,6 +54,7 @@ func (q *HFQuote) Init() {
 
 func (q *HFQuote) Release() {
 	q.IsLogin = false
+	q.recovering = false
 	q.ReleaseAPI()
 	q.FrontDisConnected(0) // 需手动触发
 }
@@ -46,6 +63,7 @@ func (q *HFQuote) Release() {
 func (q *HFQuote) ReqLogin(investor, pwd, broker string) {
 	q.InvestorID = investor
 	q.BrokerID = broker
+	q.passWord = pwd
 	f := ctpdefine.CThostFtdcReqUserLoginField{}
 	copy(f.UserID[:], q.InvestorID)
 	copy(f.BrokerID[:], q.BrokerID)
@@ -54,12 +72,92 @@ func (q *HFQuote) ReqLogin(investor, pwd, broker string) {
 	q.ReqUserLogin(&f, 1)
 }
 
+// ReqSubscript 订阅行情, 并记录以便断线重连后重新订阅
 func (q *HFQuote) ReqSubscript(instruments ...string) {
 	if len(instruments) > 0 {
+		q.setPending(instruments)
 		q.ReqSubMarketData(instruments...)
 	}
 }
 
+func (q *HFQuote) setPending(instruments []string) {
+	for _, inst := range instruments {
+		q.subscribed.Store(inst, &SubscribeField{InstrumentID: inst, Status: SubscribeStatusPending})
+	}
+}
+
+// ReqUnSubscript 退订行情, 并从 Ticks 中删除
+func (q *HFQuote) ReqUnSubscript(instruments ...string) {
+	if len(instruments) > 0 {
+		q.ReqUnSubMarketData(instruments...)
+		for _, inst := range instruments {
+			q.subscribed.Delete(inst)
+			q.Ticks.Delete(inst)
+		}
+	}
+}
+
+// SubscribeForQuoteRsp 订阅询价通知(做市商), 并记录以便断线重连后重新订阅; 未实现 ReqSubForQuote 时返回错误
+func (q *HFQuote) SubscribeForQuoteRsp(instruments ...string) error {
+	if q.ReqSubForQuote == nil {
+		return fmt.Errorf("[%d] 未实现 ReqSubForQuote", -1)
+	}
+	if len(instruments) > 0 {
+		for _, inst := range instruments {
+			q.forQuotes.Store(inst, struct{}{})
+		}
+		q.ReqSubForQuote(instruments...)
+	}
+	return nil
+}
+
+// UnSubscribeForQuoteRsp 退订询价通知; 未实现 ReqUnSubForQuote 时返回错误
+func (q *HFQuote) UnSubscribeForQuoteRsp(instruments ...string) error {
+	if q.ReqUnSubForQuote == nil {
+		return fmt.Errorf("[%d] 未实现 ReqUnSubForQuote", -1)
+	}
+	if len(instruments) > 0 {
+		q.ReqUnSubForQuote(instruments...)
+		for _, inst := range instruments {
+			q.forQuotes.Delete(inst)
+		}
+	}
+	return nil
+}
+
+// Subscribed 已订阅的合约(不含订阅失败的)
+func (q *HFQuote) Subscribed() []string {
+	instruments := make([]string, 0)
+	q.subscribed.Range(func(key, value interface{}) bool {
+		if value.(*SubscribeField).Status != SubscribeStatusFailed {
+			instruments = append(instruments, key.(string))
+		}
+		return true
+	})
+	sort.Strings(instruments)
+	return instruments
+}
+
+// GetSubscribe 合约的订阅状态, 未订阅(或已退订)返回 nil
+func (q *HFQuote) GetSubscribe(instrument string) *SubscribeField {
+	if v, ok := q.subscribed.Load(instrument); ok {
+		var f = *v.(*SubscribeField)
+		return &f
+	}
+	return nil
+}
+
+// Subscriptions 全部合约的订阅状态
+func (q *HFQuote) Subscriptions() []SubscribeField {
+	fields := make([]SubscribeField, 0)
+	q.subscribed.Range(func(key, value interface{}) bool {
+		fields = append(fields, *value.(*SubscribeField))
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].InstrumentID < fields[j].InstrumentID })
+	return fields
+}
+
 // RegOnFrontConnected 注册前置响应
 func (q *HFQuote) RegOnFrontConnected(on OnFrontConnectedType) {
 	q.onFrontConnected = on
@@ -80,6 +178,27 @@ func (q *HFQuote) RegOnTick(on OnTickType) {
 	q.onTick = on
 }
 
+// RegOnRspSubMarketData 注册订阅响应
+func (q *HFQuote) RegOnRspSubMarketData(on OnRspSubMarketDataType) {
+	q.onRspSubMarketData = on
+}
+
+// RegOnRspUnSubMarketData 注册退订响应
+func (q *HFQuote) RegOnRspUnSubMarketData(on OnRspUnSubMarketDataType) {
+	q.onRspUnSubMarketData = on
+}
+
+// RegOnRtnForQuote 注册询价通知
+func (q *HFQuote) RegOnRtnForQuote(on OnRtnForQuoteType) {
+	q.onRtnForQuote = on
+}
+
+// RegOnRecovered 注册断线恢复响应: 登录后断线, 重连时自动以原帐号登录并重新订阅, 完成(或登录失败)后响应.
+// 恢复过程不再响应 OnFrontConnected/OnRspUserLogin; 登录失败时保持恢复状态, 直至登录成功或 Release
+func (q *HFQuote) RegOnRecovered(on OnRecoveredType) {
+	q.onRecovered = on
+}
+
 func (q *HFQuote) RtnDepthMarketData(dataField *ctpdefine.CThostFtdcDepthMarketDataField) {
 	tick := TickField{
 		TradingDay:      Bytes2String(dataField.TradingDay[:]),
@@ -132,6 +251,31 @@ func (q *HFQuote) RtnDepthMarketData(dataField *ctpdefine.CThostFtdcDepthMarketD
 func (q *HFQuote) RspUserLogin(loginField *ctpdefine.CThostFtdcRspUserLoginField, infoField *ctpdefine.CThostFtdcRspInfoField) {
 	q.IsLogin = infoField.ErrorID == 0
 
+	if q.recovering { // 断线恢复: 重新订阅. 登录失败时保持恢复状态, 下次重连时再次登录
+		q.recovering = !q.IsLogin
+		instruments := q.Subscribed()
+		if q.IsLogin && len(instruments) > 0 {
+			q.setPending(instruments)
+			q.ReqSubMarketData(instruments...)
+		}
+		if q.IsLogin {
+			var forQuotes []string
+			q.forQuotes.Range(func(key, _ interface{}) bool {
+				forQuotes = append(forQuotes, key.(string))
+				return true
+			})
+			if len(forQuotes) > 0 && q.ReqSubForQuote != nil {
+				q.ReqSubForQuote(forQuotes...)
+			}
+		}
+		if q.onRecovered != nil {
+			q.onRecovered(instruments, &RspInfoField{
+				ErrorID:  int(infoField.ErrorID),
+				ErrorMsg: Bytes2String(infoField.ErrorMsg[:]),
+			})
+		}
+		return
+	}
 	if q.onRspUserLogin == nil {
 		return
 	}
@@ -151,13 +295,75 @@ func (q *HFQuote) RspUserLogin(loginField *ctpdefine.CThostFtdcRspUserLoginField
 	})
 }
 
+func (q *HFQuote) RspSubMarketData(field *ctpdefine.CThostFtdcSpecificInstrumentField, infoField *ctpdefine.CThostFtdcRspInfoField) {
+	instrument := Bytes2String(field.InstrumentID[:])
+	info := &RspInfoField{}
+	if infoField != nil {
+		info.ErrorID = int(infoField.ErrorID)
+		info.ErrorMsg = Bytes2String(infoField.ErrorMsg[:])
+	}
+	if _, ok := q.subscribed.Load(instrument); ok { // 未经 ReqSubscript 订阅的不记录
+		sub := &SubscribeField{InstrumentID: instrument, Status: SubscribeStatusActive}
+		if info.ErrorID != 0 {
+			sub.Status = SubscribeStatusFailed
+			sub.ErrorID = info.ErrorID
+			sub.ErrorMsg = info.ErrorMsg
+		}
+		q.subscribed.Store(instrument, sub)
+	}
+	if q.onRspSubMarketData != nil {
+		q.onRspSubMarketData(instrument, info)
+	}
+}
+
+func (q *HFQuote) RspUnSubMarketData(field *ctpdefine.CThostFtdcSpecificInstrumentField, infoField *ctpdefine.CThostFtdcRspInfoField) {
+	instrument := Bytes2String(field.InstrumentID[:])
+	info := &RspInfoField{}
+	if infoField != nil {
+		info.ErrorID = int(infoField.ErrorID)
+		info.ErrorMsg = Bytes2String(infoField.ErrorMsg[:])
+	}
+	if info.ErrorID == 0 { // 请求与响应之间推送的行情
+		q.Ticks.Delete(instrument)
+	}
+	if q.onRspUnSubMarketData != nil {
+		q.onRspUnSubMarketData(instrument, info)
+	}
+}
+
+// RtnForQuoteRsp 询价通知
+func (q *HFQuote) RtnForQuoteRsp(field *ctpdefine.CThostFtdcForQuoteRspField) {
+	if q.onRtnForQuote != nil {
+		q.onRtnForQuote(newForQuoteField(field))
+	}
+}
+
+func newForQuoteField(field *ctpdefine.CThostFtdcForQuoteRspField) *ForQuoteField {
+	return &ForQuoteField{
+		TradingDay:    Bytes2String(field.TradingDay[:]),
+		InstrumentID:  Bytes2String(field.InstrumentID[:]),
+		ExchangeID:    Bytes2String(field.ExchangeID[:]),
+		ForQuoteSysID: Bytes2String(field.ForQuoteSysID[:]),
+		ForQuoteTime:  Bytes2String(field.ForQuoteTime[:]),
+		ActionDay:     Bytes2String(field.ActionDay[:]),
+	}
+}
+
 func (q *HFQuote) FrontConnected() {
+	if q.recovering { // 断线重连: 以原帐号登录
+		q.ReqLogin(q.InvestorID, q.passWord, q.BrokerID)
+		return
+	}
 	if q.onFrontConnected != nil {
 		q.onFrontConnected()
 	}
 }
 
 func (q *HFQuote) FrontDisConnected(reason int) {
+	if q.IsLogin { // Release 时已置为 false, 不恢复
+		q.IsLogin = false
+		q.recovering = true
+	}
 	if q.onFrontDisConnected != nil {
 		q.onFrontDisConnected(reason)
 	}
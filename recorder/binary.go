@@ -0,0 +1,215 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// magic 二进制文件头
+const magic = "GOCTPTK1"
+
+// 二进制格式:
+//   文件头: magic, 字段数(uvarint), 每个字段: 名称(uvarint 长度+字节) 类型(1 字节 s/f/i)
+//   记录:   按文件头的字段顺序, string 为 uvarint 长度+字节, float64 为 8 字节小端, int 为 varint
+// 文件头自描述字段, TickField 增减字段后旧文件仍可读取
+
+type binaryEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+func newBinaryEncoder(w io.Writer, header bool) (*binaryEncoder, error) {
+	e := &binaryEncoder{w: w}
+	if header {
+		buf := []byte(magic)
+		buf = appendUvarint(buf, uint64(len(tickFields)))
+		for _, sf := range tickFields {
+			buf = appendString(buf, sf.Name)
+			buf = append(buf, kindCode(sf.Type.Kind()))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func (e *binaryEncoder) encode(tick *goctp.TickField) error {
+	v := reflect.ValueOf(tick).Elem()
+	buf := e.buf[:0]
+	for _, sf := range tickFields {
+		fv := v.FieldByIndex(sf.Index)
+		switch fv.Kind() {
+		case reflect.String:
+			buf = appendString(buf, fv.String())
+		case reflect.Float64:
+			buf = appendFloat(buf, fv.Float())
+		case reflect.Int:
+			buf = appendVarint(buf, fv.Int())
+		}
+	}
+	e.buf = buf
+	_, err := e.w.Write(buf)
+	return err
+}
+
+func (e *binaryEncoder) flush() error {
+	return nil
+}
+
+type binaryDecoder struct {
+	r     *bufio.Reader
+	kinds []byte
+	idx   []int // 字段对应 TickField 的序号, -1 忽略
+}
+
+func newBinaryDecoder(r *bufio.Reader) (*binaryDecoder, error) {
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, head); err != nil || string(head) != magic {
+		return nil, errors.New("不是行情二进制文件")
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("读文件头: %w", err)
+	}
+	d := &binaryDecoder{r: r, kinds: make([]byte, n), idx: make([]int, n)}
+	for i := range d.kinds {
+		name, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("读文件头: %w", err)
+		}
+		if d.kinds[i], err = r.ReadByte(); err != nil {
+			return nil, fmt.Errorf("读文件头: %w", err)
+		}
+		d.idx[i] = fieldIndex(name)
+	}
+	return d, nil
+}
+
+func (d *binaryDecoder) decode() (*goctp.TickField, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err // io.EOF
+	}
+	tick := new(goctp.TickField)
+	v := reflect.ValueOf(tick).Elem()
+	for i, kind := range d.kinds {
+		var (
+			s   string
+			x   float64
+			n   int64
+			err error
+		)
+		switch kind {
+		case 's':
+			s, err = readString(d.r)
+		case 'f':
+			var bs [8]byte
+			_, err = io.ReadFull(d.r, bs[:])
+			x = math.Float64frombits(binary.LittleEndian.Uint64(bs[:]))
+		case 'i':
+			n, err = binary.ReadVarint(d.r)
+		default:
+			return nil, fmt.Errorf("未知的字段类型 %q", kind)
+		}
+		if err != nil { // 记录不完整(写入时中断)
+			return nil, io.ErrUnexpectedEOF
+		}
+		if d.idx[i] < 0 {
+			continue
+		}
+		fv := v.Field(d.idx[i])
+		switch {
+		case kind == 's' && fv.Kind() == reflect.String:
+			fv.SetString(s)
+		case kind == 'f' && fv.Kind() == reflect.Float64:
+			fv.SetFloat(x)
+		case kind == 'i' && fv.Kind() == reflect.Int:
+			fv.SetInt(n)
+		}
+	}
+	return tick, nil
+}
+
+// binaryCompleteSize 完整的文件头及记录的长度, 文件头不完整时为 0
+func binaryCompleteSize(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	br := bufio.NewReader(cr)
+	if head, err := br.Peek(len(magic)); err == nil && string(head) != magic {
+		return 0, errors.New("不是行情二进制文件")
+	}
+	d, err := newBinaryDecoder(br)
+	if err != nil {
+		return 0, nil
+	}
+	size := cr.n - int64(br.Buffered())
+	for {
+		if _, err = d.decode(); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, nil
+		} else if err != nil {
+			return size, err
+		}
+		size = cr.n - int64(br.Buffered())
+	}
+}
+
+// countReader 记录已读取的字节数
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func kindCode(k reflect.Kind) byte {
+	switch k {
+	case reflect.String:
+		return 's'
+	case reflect.Float64:
+		return 'f'
+	}
+	return 'i'
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var bs [binary.MaxVarintLen64]byte
+	return append(buf, bs[:binary.PutUvarint(bs[:], x)]...)
+}
+
+func appendVarint(buf []byte, x int64) []byte {
+	var bs [binary.MaxVarintLen64]byte
+	return append(buf, bs[:binary.PutVarint(bs[:], x)]...)
+}
+
+func appendFloat(buf []byte, x float64) []byte {
+	var bs [8]byte
+	binary.LittleEndian.PutUint64(bs[:], math.Float64bits(x))
+	return append(buf, bs[:]...)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	bs := make([]byte, n)
+	if _, err = io.ReadFull(r, bs); err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
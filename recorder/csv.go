@@ -0,0 +1,131 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// csvEncoder csv 格式: 首行为 TickField 的字段名, 其后每行一笔行情
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer, header bool) (*csvEncoder, error) {
+	e := &csvEncoder{w: csv.NewWriter(w)}
+	if header {
+		names := make([]string, len(tickFields))
+		for i, sf := range tickFields {
+			names[i] = sf.Name
+		}
+		if err := e.w.Write(names); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+func (e *csvEncoder) encode(tick *goctp.TickField) error {
+	v := reflect.ValueOf(tick).Elem()
+	record := make([]string, len(tickFields))
+	for i, sf := range tickFields {
+		fv := v.FieldByIndex(sf.Index)
+		switch fv.Kind() {
+		case reflect.String:
+			record[i] = fv.String()
+		case reflect.Float64:
+			record[i] = strconv.FormatFloat(fv.Float(), 'g', -1, 64)
+		case reflect.Int:
+			record[i] = strconv.FormatInt(fv.Int(), 10)
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvCompleteSize 到最后一个换行符(含)的长度: 之后为不完整的行
+func csvCompleteSize(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 4096)
+	for end := info.Size(); end > 0; {
+		n := int64(len(buf))
+		if n > end {
+			n = end
+		}
+		if _, err := f.ReadAt(buf[:n], end-n); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return end - n + int64(i) + 1, nil
+		}
+		end -= n
+	}
+	return 0, nil
+}
+
+// csvDecoder 按表头的字段名解析(顺序/数量不限, 未知列忽略)
+type csvDecoder struct {
+	r      *csv.Reader
+	header []string
+	idx    []int // 列对应的字段序号, -1 忽略
+	line   int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	d := &csvDecoder{r: csv.NewReader(r), line: 1}
+	d.r.FieldsPerRecord = -1
+	header, err := d.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读表头: %w", err)
+	}
+	d.header = header
+	d.idx = make([]int, len(header))
+	for i, name := range header {
+		d.idx[i] = fieldIndex(name)
+	}
+	return d, nil
+}
+
+func (d *csvDecoder) decode() (*goctp.TickField, error) {
+	record, err := d.r.Read()
+	if err != nil {
+		return nil, err // io.EOF
+	}
+	d.line++
+	tick := new(goctp.TickField)
+	v := reflect.ValueOf(tick).Elem()
+	for i, s := range record {
+		if i >= len(d.idx) || d.idx[i] < 0 || len(s) == 0 {
+			continue
+		}
+		fv := v.Field(d.idx[i])
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString(s)
+		case reflect.Float64, reflect.Int:
+			x, err := strconv.ParseFloat(s, 64) // 兼容 int 列写为 "12.0"
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行 %s: %w", d.line, d.header[i], err)
+			}
+			if fv.Kind() == reflect.Int {
+				fv.SetInt(int64(x))
+			} else {
+				fv.SetFloat(x)
+			}
+		}
+	}
+	return tick, nil
+}
@@ -0,0 +1,27 @@
+package recorder
+
+import (
+	"reflect"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// tickFields TickField 的字段, 按定义顺序作为 csv 表头与二进制记录的字段顺序
+var tickFields = func() (fields []reflect.StructField) {
+	typ := reflect.TypeOf(goctp.TickField{})
+	for i := 0; i < typ.NumField(); i++ {
+		switch typ.Field(i).Type.Kind() {
+		case reflect.String, reflect.Float64, reflect.Int:
+			fields = append(fields, typ.Field(i))
+		}
+	}
+	return
+}()
+
+// fieldIndex 按字段名取 TickField 的字段序号, 不存在返回 -1
+func fieldIndex(name string) int {
+	if sf, ok := reflect.TypeOf(goctp.TickField{}).FieldByName(name); ok {
+		return sf.Index[0]
+	}
+	return -1
+}
@@ -0,0 +1,101 @@
+package recorder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// Reader 按写入顺序读取行情文件(csv 或二进制, 按文件头识别)
+type Reader struct {
+	file string
+	f    *os.File
+	dec  interface {
+		decode() (*goctp.TickField, error)
+	}
+}
+
+// Open 打开行情文件
+func Open(file string) (*Reader, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{file: file, f: f}
+	br := bufio.NewReader(f)
+	if head, _ := br.Peek(len(magic)); string(head) == magic {
+		r.dec, err = newBinaryDecoder(br)
+	} else {
+		r.dec, err = newCSVDecoder(br)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return r, nil
+}
+
+// Read 读取下一笔行情, 读完返回 io.EOF
+func (r *Reader) Read() (*goctp.TickField, error) {
+	tick, err := r.dec.decode()
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("%s: %w", r.file, err)
+	}
+	return tick, err
+}
+
+// Close 关闭文件
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
+
+// ReadFile 读取文件中的全部行情
+func ReadFile(file string) ([]*goctp.TickField, error) {
+	r, err := Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var ticks []*goctp.TickField
+	for {
+		tick, err := r.Read()
+		if err == io.EOF {
+			return ticks, nil
+		}
+		if err != nil {
+			return ticks, err
+		}
+		ticks = append(ticks, tick)
+	}
+}
+
+// Files 交易日的行情文件, instruments 为空时取全部合约
+func Files(dir, tradingDay string, instruments ...string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, tradingDay))
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(instruments))
+	for _, inst := range instruments {
+		want[inst] = true
+	}
+	var files []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != FormatCSV.Ext() && ext != FormatBinary.Ext()) {
+			continue
+		}
+		if len(want) > 0 && !want[strings.TrimSuffix(e.Name(), ext)] {
+			continue
+		}
+		files = append(files, filepath.Join(dir, tradingDay, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
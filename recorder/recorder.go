@@ -0,0 +1,232 @@
+package recorder
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// Format 文件格式
+type Format int
+
+const (
+	// FormatCSV csv 文本
+	FormatCSV Format = iota
+	// FormatBinary 二进制(约为 csv 的一半大小)
+	FormatBinary
+)
+
+// Ext 文件扩展名
+func (f Format) Ext() string {
+	if f == FormatBinary {
+		return ".bin"
+	}
+	return ".csv"
+}
+
+// Recorder 行情记录: 每笔 tick 写入 Dir/交易日/合约.csv(.bin), 交易日切换时关闭前一交易日的文件
+type Recorder struct {
+	Dir    string
+	Format Format
+
+	mu        sync.Mutex
+	files     map[string]*tickFile // key: 交易日/合约
+	day       string               // 最新交易日
+	lastFlush time.Time
+	errCount  int   // 写入失败的次数
+	lastErr   error // 最近的写入错误
+}
+
+type encoder interface {
+	encode(tick *goctp.TickField) error
+	flush() error
+}
+
+type tickFile struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc encoder
+}
+
+// flushInterval 缓存写入磁盘的间隔
+const flushInterval = time.Second
+
+// NewRecorder 实例化
+func NewRecorder(dir string, format Format) *Recorder {
+	return &Recorder{
+		Dir:    dir,
+		Format: format,
+		files:  make(map[string]*tickFile),
+	}
+}
+
+// Attach 挂接到行情接口(替代 RegOnTick), 先记录再调用 on(可为 nil). 写入失败计入 Errors
+func (r *Recorder) Attach(q *goctp.HFQuote, on goctp.OnTickType) {
+	q.RegOnTick(func(tick *goctp.TickField) {
+		r.Write(tick)
+		if on != nil {
+			on(tick)
+		}
+	})
+}
+
+// Errors 写入失败的次数及最近的错误
+func (r *Recorder) Errors() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.errCount, r.lastErr
+}
+
+// Write 记录一笔行情
+func (r *Recorder) Write(tick *goctp.TickField) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.write(tick)
+	if err != nil {
+		r.errCount++
+		r.lastErr = err
+	}
+	return err
+}
+
+func (r *Recorder) write(tick *goctp.TickField) error {
+	day := tick.TradingDay
+	if len(day) == 0 {
+		day = time.Now().Local().Format("20060102")
+	}
+	if day > r.day { // 交易日切换
+		if err := r.closeDay(day); err != nil {
+			return err
+		}
+		r.day = day
+	}
+	key := day + "/" + tick.InstrumentID
+	tf, ok := r.files[key]
+	if !ok {
+		var err error
+		if tf, err = r.open(day, tick.InstrumentID); err != nil {
+			return err
+		}
+		r.files[key] = tf
+	}
+	if err := tf.enc.encode(tick); err != nil {
+		return err
+	}
+	if day < r.day { // 交易日切换后迟到的行情: 写入后即关闭前一交易日的文件
+		delete(r.files, key)
+		err := tf.flush()
+		if e := tf.f.Close(); e != nil && err == nil {
+			err = e
+		}
+		return err
+	}
+	if time.Since(r.lastFlush) >= flushInterval {
+		r.lastFlush = time.Now()
+		return r.flush()
+	}
+	return nil
+}
+
+// Flush 缓存写入磁盘
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flush()
+}
+
+// Close 关闭所有文件
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeDay("")
+}
+
+// Path 行情文件路径
+func Path(dir, tradingDay, instrumentID string, format Format) string {
+	return filepath.Join(dir, tradingDay, instrumentID+format.Ext())
+}
+
+func (r *Recorder) open(day, instrumentID string) (*tickFile, error) {
+	file := Path(r.Dir, day, instrumentID, r.Format)
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return nil, err
+	}
+	size, err := completeSize(file, r.Format)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Truncate(file, size); err != nil && !os.IsNotExist(err) { // 去掉进程中止时写入的不完整记录
+		return nil, err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	header := size == 0 // 重启后追加写入时不再写文件头
+	tf := &tickFile{f: f, w: bufio.NewWriter(f)}
+	if r.Format == FormatBinary {
+		tf.enc, err = newBinaryEncoder(tf.w, header)
+	} else {
+		tf.enc, err = newCSVEncoder(tf.w, header)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return tf, nil
+}
+
+// completeSize 文件中完整记录的长度(不存在为 0). 缓存按 4096 字节而非记录边界写入磁盘,
+// 进程被中止时文件末尾可能有不完整的记录, 其后追加的记录将错位
+func completeSize(file string, format Format) (int64, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if format == FormatBinary {
+		return binaryCompleteSize(f)
+	}
+	return csvCompleteSize(f)
+}
+
+func (r *Recorder) flush() (err error) {
+	for _, tf := range r.files {
+		if e := tf.flush(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// closeDay 关闭 day 之外的文件(day 为空时全部关闭)
+func (r *Recorder) closeDay(day string) (err error) {
+	for key, tf := range r.files {
+		if len(day) > 0 && strings.HasPrefix(key, day+"/") {
+			continue
+		}
+		if e := tf.flush(); e != nil && err == nil {
+			err = e
+		}
+		if e := tf.f.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(r.files, key)
+	}
+	return
+}
+
+func (tf *tickFile) flush() error {
+	if err := tf.enc.flush(); err != nil {
+		return err
+	}
+	return tf.w.Flush()
+}
@@ -0,0 +1,112 @@
+package recorder
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"gitee.com/haifengat/goctp"
+)
+
+// tick rb2305 交易日 20230105 的行情
+func tick(updateTime string, price float64, volume int) *goctp.TickField {
+	return &goctp.TickField{
+		TradingDay:   "20230105",
+		InstrumentID: "rb2305",
+		ActionDay:    "20230105",
+		UpdateTime:   updateTime,
+		LastPrice:    price,
+		BidPrice1:    price - 1,
+		AskPrice1:    price + 1,
+		Volume:       volume,
+		OpenInterest: 100000.5,
+	}
+}
+
+// record 写入行情并关闭
+func record(t *testing.T, dir string, format Format, ticks ...*goctp.TickField) {
+	r := NewRecorder(dir, format)
+	for _, tk := range ticks {
+		if err := r.Write(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// check 文件中的行情应与 want 一致
+func check(t *testing.T, file string, want ...*goctp.TickField) {
+	got, err := ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("读取 %d 笔, 应为 %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("第 %d 笔: %+v, 应为 %+v", i, *got[i], *want[i])
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatBinary} {
+		dir := t.TempDir()
+		ticks := []*goctp.TickField{tick("09:00:00", 3000, 10), tick("09:00:00", 3000.5, 12), tick("09:00:01", 2999, 20)}
+		record(t, dir, format, ticks...)
+		check(t, Path(dir, "20230105", "rb2305", format), ticks...)
+		// 重启后追加: 不重复写入文件头
+		more := tick("09:00:02", 3001, 25)
+		record(t, dir, format, more)
+		check(t, Path(dir, "20230105", "rb2305", format), append(ticks, more)...)
+	}
+}
+
+func TestTornWrite(t *testing.T) {
+	for _, format := range []Format{FormatCSV, FormatBinary} {
+		dir := t.TempDir()
+		file := Path(dir, "20230105", "rb2305", format)
+		first := tick("09:00:00", 3000, 10)
+		record(t, dir, format, first)
+		size, err := completeSize(file, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record(t, dir, format, tick("09:00:01", 3001, 11))
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Truncate(file, info.Size()-3); err != nil { // 进程中止: 最后一笔只写入一部分
+			t.Fatal(err)
+		}
+		if got, err := completeSize(file, format); err != nil || got != size {
+			t.Fatalf("%s 完整长度 %d(%v), 应为 %d", format.Ext(), got, err, size)
+		}
+		next := tick("09:00:02", 3002, 12)
+		record(t, dir, format, next)
+		check(t, file, first, next)
+	}
+}
+
+func TestLateTick(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRecorder(dir, FormatCSV)
+	defer r.Close()
+	night := tick("23:00:00", 3000, 10)
+	day := tick("21:00:00", 3001, 5)
+	day.TradingDay = "20230106"
+	late := tick("23:00:01", 3000, 11) // 交易日切换后迟到
+	for _, tk := range []*goctp.TickField{night, day, late} {
+		if err := r.Write(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(r.files) != 1 {
+		t.Fatalf("应只保留当前交易日的文件, 实际 %d 个", len(r.files))
+	}
+	check(t, Path(dir, "20230105", "rb2305", FormatCSV), night, late)
+}
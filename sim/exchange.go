@@ -0,0 +1,902 @@
+package sim
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// 错误代码(取自 CTP error.xml)
+const (
+	errInvalidLogin       = 3  // 不合法的登录
+	errBadField           = 15 // 报单字段有误
+	errInstrumentNotFound = 16 // 找不到合约
+	errOrderNotFound      = 25 // 撤单找不到相应报单
+	errOrderFinished      = 26 // 报单已全成交或已撤销，不能再撤
+	errOverClosePosition  = 30 // 平仓量超过持仓量
+	errInsufficientMoney  = 31 // 资金不足
+)
+
+var errMsgs = map[int]string{
+	errInvalidLogin:       "CTP:不合法的登录",
+	errBadField:           "CTP:报单字段有误",
+	errInstrumentNotFound: "CTP:找不到合约",
+	errOrderNotFound:      "CTP:撤单找不到相应报单",
+	errOrderFinished:      "CTP:报单已全成交或已撤销，不能再撤",
+	errOverClosePosition:  "CTP:平仓量超过持仓量",
+	errInsufficientMoney:  "CTP:资金不足",
+}
+
+func rspInfo(errID int) *ctp.CThostFtdcRspInfoField {
+	info := ctp.CThostFtdcRspInfoField{ErrorID: ctp.TThostFtdcErrorIDType(errID)}
+	if errID == 0 {
+		copyGBK(info.ErrorMsg[:], "正确")
+	} else {
+		copyGBK(info.ErrorMsg[:], errMsgs[errID])
+	}
+	return &info
+}
+
+// Exchange 模拟交易所(柜台): 维护合约/帐户/持仓, 以行情的对价撮合委托
+type Exchange struct {
+	TradingDay  string  // 交易日(默认当天)
+	MarginRatio float64 // 保证金率(按金额, 默认 0.1)
+	Commission  float64 // 手续费(每手)
+
+	mu          sync.Mutex
+	instruments map[string]*goctp.InstrumentField
+	status      map[string]goctp.InstrumentStatusType
+	ticks       map[string]*goctp.TickField
+	accounts    map[string]*account
+	positions   map[string]*position // key: investor_instrument_direction_hedge
+	orders      []*order             // 当日委托
+	trades      []*ctp.CThostFtdcTradeField
+	sessions    []*Trade // 已登录的会话
+	updateTime  string   // 最新行情时间, 回放时作为成交时间
+
+	sessionID int
+	sysID     int
+	tradeID   int
+}
+
+// account 资金
+type account struct {
+	investor    string
+	preBalance  float64
+	deposit     float64
+	withdraw    float64
+	closeProfit float64
+	commission  float64
+}
+
+// position 持仓
+type position struct {
+	investor    string
+	instrument  string
+	direction   byte // ctp.THOST_FTDC_PD_Long/Short
+	hedge       byte
+	ydInit      int     // 上日持仓(不随平仓变化)
+	yd          int     // 剩余昨仓
+	td          int     // 今仓
+	ydFrozen    int     // 平昨冻结
+	tdFrozen    int     // 平今冻结
+	openCost    float64 // 开仓成本(含乘数)
+	openVolume  int
+	closeVolume int
+	closeProfit float64
+	commission  float64
+}
+
+// order 委托
+type order struct {
+	field      ctp.CThostFtdcOrderField // 回报
+	investor   string
+	instrument string
+	direction  byte
+	offset     byte
+	hedge      byte
+	priceType  byte
+	timeCond   byte
+	volCond    byte
+	price      float64
+	volume     int
+	traded     int
+	ydFrozen   int // 平仓冻结的昨仓
+	tdFrozen   int // 平仓冻结的今仓
+}
+
+func (o *order) left() int {
+	return o.volume - o.traded
+}
+
+func (o *order) isWorking() bool {
+	switch o.field.OrderStatus {
+	case ctp.THOST_FTDC_OST_Unknown, ctp.THOST_FTDC_OST_NoTradeQueueing, ctp.THOST_FTDC_OST_PartTradedQueueing:
+		return true
+	}
+	return false
+}
+
+// liquidity 一笔行情可成交的数量(0: 不限, <0: 已用完)
+type liquidity struct {
+	ask, bid int
+}
+
+// NewExchange 实例化
+func NewExchange() *Exchange {
+	return &Exchange{
+		TradingDay:  time.Now().Local().Format("20060102"),
+		MarginRatio: 0.1,
+		instruments: make(map[string]*goctp.InstrumentField),
+		status:      make(map[string]goctp.InstrumentStatusType),
+		ticks:       make(map[string]*goctp.TickField),
+		accounts:    make(map[string]*account),
+		positions:   make(map[string]*position),
+		sessionID:   10000,
+	}
+}
+
+// AddInstrument 添加合约
+func (ex *Exchange) AddInstrument(inst *goctp.InstrumentField) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	var f = *inst
+	ex.instruments[f.InstrumentID] = &f
+}
+
+// AddAccount 添加帐户(登录用户即帐户), balance 为上日权益
+func (ex *Exchange) AddAccount(investorID string, balance float64) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if acc, ok := ex.accounts[investorID]; ok {
+		acc.preBalance = balance
+		return
+	}
+	ex.accounts[investorID] = &account{investor: investorID, preBalance: balance}
+}
+
+// SetPosition 设置昨仓(登录前调用)
+func (ex *Exchange) SetPosition(investorID, instrumentID string, direction goctp.PosiDirectionType, volume int, price float64) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	p := ex.getPosition(investorID, instrumentID, byte(direction), byte(goctp.HedgeFlagSpeculation))
+	p.ydInit = volume
+	p.yd = volume
+	p.openCost = price * float64(volume) * ex.multiple(instrumentID)
+}
+
+// SetInstrumentStatus 合约状态变化(推送给已登录的会话)
+func (ex *Exchange) SetInstrumentStatus(instrumentID string, status goctp.InstrumentStatusType) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	ex.status[instrumentID] = status
+	for _, s := range ex.sessions {
+		f := ex.statusField(instrumentID)
+		s.post(func() { s.HFTrade.RtnInstrumentStatus(&f) })
+	}
+	ex.matchInstrument(instrumentID)
+}
+
+func (ex *Exchange) hasInstrument(instrumentID string) bool {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	_, ok := ex.instruments[instrumentID]
+	return ok
+}
+
+// UpdateTick 更新行情并撮合挂单
+func (ex *Exchange) UpdateTick(tick *goctp.TickField) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	var f = *tick
+	ex.ticks[f.InstrumentID] = &f
+	if len(f.UpdateTime) > 0 {
+		ex.updateTime = f.UpdateTime
+	}
+	ex.matchInstrument(f.InstrumentID)
+}
+
+// ---------------- 会话 ----------------
+
+func (ex *Exchange) login(t *Trade, userID string) (sessionID int, errID int) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	if _, ok := ex.accounts[userID]; !ok {
+		return 0, errInvalidLogin
+	}
+	ex.sessionID++
+	t.investorID = userID
+	t.sessionID = ex.sessionID
+	ex.sessions = append(ex.sessions, t)
+	return ex.sessionID, 0
+}
+
+// publish 公有流: 登录后推送合约状态
+func (ex *Exchange) publish(t *Trade) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	for instrumentID := range ex.status {
+		f := ex.statusField(instrumentID)
+		t.post(func() { t.HFTrade.RtnInstrumentStatus(&f) })
+	}
+}
+
+func (ex *Exchange) logout(t *Trade) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	for i, s := range ex.sessions {
+		if s == t {
+			ex.sessions = append(ex.sessions[:i], ex.sessions[i+1:]...)
+			break
+		}
+	}
+}
+
+// notify 私有流推送至该帐户的所有会话
+func (ex *Exchange) notify(investor string, fn func(s *Trade)) {
+	for _, s := range ex.sessions {
+		if s.investorID == investor {
+			fn(s)
+		}
+	}
+}
+
+// ---------------- 委托 ----------------
+
+func (ex *Exchange) insertOrder(t *Trade, input *ctp.CThostFtdcInputOrderField) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	var in = *input
+	reject := func(errID int) {
+		t.post(func() { t.HFTrade.ErrRtnOrderInsert(&in, rspInfo(errID)) })
+	}
+	o := &order{
+		investor:   goctp.Bytes2String(in.InvestorID[:]),
+		instrument: goctp.Bytes2String(in.InstrumentID[:]),
+		direction:  byte(in.Direction),
+		offset:     in.CombOffsetFlag[0],
+		hedge:      in.CombHedgeFlag[0],
+		priceType:  byte(in.OrderPriceType),
+		timeCond:   byte(in.TimeCondition),
+		volCond:    byte(in.VolumeCondition),
+		price:      float64(in.LimitPrice),
+		volume:     int(in.VolumeTotalOriginal),
+	}
+	if o.investor == "" {
+		o.investor = t.investorID
+	}
+	if o.hedge == 0 {
+		o.hedge = ctp.THOST_FTDC_HF_Speculation
+	}
+	inst, ok := ex.instruments[o.instrument]
+	if !ok {
+		reject(errInstrumentNotFound)
+		return
+	}
+	if o.volume <= 0 || (o.priceType == ctp.THOST_FTDC_OPT_LimitPrice && o.price <= 0) {
+		reject(errBadField)
+		return
+	}
+	if tick, ok := ex.ticks[o.instrument]; ok && o.priceType == ctp.THOST_FTDC_OPT_LimitPrice {
+		if (goctp.ValidPrice(tick.UpperLimitPrice) && o.price > tick.UpperLimitPrice+1e-8) || (goctp.ValidPrice(tick.LowerLimitPrice) && o.price < tick.LowerLimitPrice-1e-8) {
+			reject(errBadField)
+			return
+		}
+	}
+	if o.offset == ctp.THOST_FTDC_OF_Open {
+		acc, ok := ex.accounts[o.investor]
+		if !ok {
+			reject(errInvalidLogin)
+			return
+		}
+		if ex.available(acc) < ex.orderMargin(o)+ex.Commission*float64(o.volume) {
+			reject(errInsufficientMoney)
+			return
+		}
+	} else if !ex.freezeClose(o, inst.ExchangeID) {
+		reject(errOverClosePosition)
+		return
+	}
+
+	// 柜台接受
+	ex.sysID++
+	f := &o.field
+	f.BrokerID = in.BrokerID
+	f.InvestorID = in.InvestorID
+	copy(f.InvestorID[:], o.investor)
+	f.UserID = in.UserID
+	f.OrderRef = in.OrderRef
+	f.InstrumentID = in.InstrumentID
+	copy(f.ExchangeID[:], inst.ExchangeID)
+	f.OrderPriceType = in.OrderPriceType
+	f.Direction = in.Direction
+	f.CombOffsetFlag = in.CombOffsetFlag
+	f.CombHedgeFlag = in.CombHedgeFlag
+	f.CombHedgeFlag[0] = o.hedge
+	f.LimitPrice = in.LimitPrice
+	f.VolumeTotalOriginal = in.VolumeTotalOriginal
+	f.TimeCondition = in.TimeCondition
+	f.GTDDate = in.GTDDate
+	f.VolumeCondition = in.VolumeCondition
+	f.MinVolume = in.MinVolume
+	f.ContingentCondition = in.ContingentCondition
+	f.StopPrice = in.StopPrice
+	f.ForceCloseReason = in.ForceCloseReason
+	f.IsAutoSuspend = in.IsAutoSuspend
+	f.BusinessUnit = in.BusinessUnit
+	f.RequestID = in.RequestID
+	f.FrontID = ctp.TThostFtdcFrontIDType(t.frontID)
+	f.SessionID = ctp.TThostFtdcSessionIDType(t.sessionID)
+	copy(f.TradingDay[:], ex.TradingDay)
+	copy(f.InsertDate[:], ex.TradingDay)
+	copy(f.InsertTime[:], ex.now())
+	f.VolumeTotal = in.VolumeTotalOriginal
+	f.OrderStatus = ctp.THOST_FTDC_OST_Unknown
+	f.OrderSubmitStatus = ctp.THOST_FTDC_OSS_InsertSubmitted
+	copyGBK(f.StatusMsg[:], "报单已提交")
+	ex.orders = append(ex.orders, o)
+	ex.rtnOrder(o)
+
+	// 交易所接受
+	copy(f.OrderSysID[:], fmt.Sprintf("%d", ex.sysID))
+	f.OrderStatus = ctp.THOST_FTDC_OST_NoTradeQueueing
+	f.OrderSubmitStatus = ctp.THOST_FTDC_OSS_Accepted
+	copyGBK(f.StatusMsg[:], "未成交")
+	ex.rtnOrder(o)
+
+	if tick, ok := ex.ticks[o.instrument]; ok {
+		ex.match(o, tick, &liquidity{ask: tick.AskVolume1, bid: tick.BidVolume1}, false)
+	}
+	// FAK/FOK/市价: 剩余撤单
+	if o.isWorking() && (o.timeCond == ctp.THOST_FTDC_TC_IOC || o.priceType == ctp.THOST_FTDC_OPT_AnyPrice) {
+		ex.cancel(o)
+	}
+}
+
+func (ex *Exchange) cancelOrder(t *Trade, action *ctp.CThostFtdcInputOrderActionField) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	var in = *action
+	reject := func(errID int) {
+		f := ctp.CThostFtdcOrderActionField{
+			BrokerID:       in.BrokerID,
+			InvestorID:     in.InvestorID,
+			OrderActionRef: in.OrderActionRef,
+			OrderRef:       in.OrderRef,
+			RequestID:      in.RequestID,
+			FrontID:        in.FrontID,
+			SessionID:      in.SessionID,
+			ExchangeID:     in.ExchangeID,
+			OrderSysID:     in.OrderSysID,
+			ActionFlag:     in.ActionFlag,
+			UserID:         in.UserID,
+			InstrumentID:   in.InstrumentID,
+		}
+		t.post(func() { t.HFTrade.ErrRtnOrderAction(&f, rspInfo(errID)) })
+	}
+	var o *order
+	sysID := goctp.Bytes2String(in.OrderSysID[:])
+	ref := goctp.Bytes2String(in.OrderRef[:])
+	for _, v := range ex.orders {
+		if len(sysID) > 0 {
+			if goctp.Bytes2String(v.field.OrderSysID[:]) == sysID {
+				o = v
+				break
+			}
+		} else if v.field.FrontID == in.FrontID && v.field.SessionID == in.SessionID && goctp.Bytes2String(v.field.OrderRef[:]) == ref {
+			o = v
+			break
+		}
+	}
+	if o == nil {
+		reject(errOrderNotFound)
+		return
+	}
+	if !o.isWorking() {
+		reject(errOrderFinished)
+		return
+	}
+	ex.cancel(o)
+}
+
+// cancel 撤单: 释放冻结并回报
+func (ex *Exchange) cancel(o *order) {
+	if o.offset != ctp.THOST_FTDC_OF_Open {
+		if p, ok := ex.positions[positionKey(o.investor, o.instrument, closeDirection(o.direction), o.hedge)]; ok {
+			p.ydFrozen -= o.ydFrozen
+			p.tdFrozen -= o.tdFrozen
+		}
+		o.ydFrozen, o.tdFrozen = 0, 0
+	}
+	f := &o.field
+	f.OrderStatus = ctp.THOST_FTDC_OST_Canceled
+	f.OrderSubmitStatus = ctp.THOST_FTDC_OSS_Accepted
+	copy(f.CancelTime[:], ex.now())
+	copyGBK(f.StatusMsg[:], "已撤单")
+	ex.rtnOrder(o)
+}
+
+// freezeClose 平仓冻结. 上期/能源: 平今只平今仓, 平仓/平昨只平昨仓; 其他交易所先平昨再平今
+func (ex *Exchange) freezeClose(o *order, exchangeID string) bool {
+	p, ok := ex.positions[positionKey(o.investor, o.instrument, closeDirection(o.direction), o.hedge)]
+	if !ok {
+		return false
+	}
+	ydAvail := p.yd - p.ydFrozen
+	tdAvail := p.td - p.tdFrozen
+	if exchangeID == "SHFE" || exchangeID == "INE" {
+		if o.offset == ctp.THOST_FTDC_OF_CloseToday {
+			if tdAvail < o.volume {
+				return false
+			}
+			o.tdFrozen = o.volume
+		} else {
+			if ydAvail < o.volume {
+				return false
+			}
+			o.ydFrozen = o.volume
+		}
+	} else {
+		if ydAvail+tdAvail < o.volume {
+			return false
+		}
+		o.ydFrozen = o.volume
+		if o.ydFrozen > ydAvail {
+			o.ydFrozen = ydAvail
+		}
+		o.tdFrozen = o.volume - o.ydFrozen
+	}
+	p.ydFrozen += o.ydFrozen
+	p.tdFrozen += o.tdFrozen
+	return true
+}
+
+// ---------------- 撮合 ----------------
+
+// matchInstrument 按委托顺序撮合合约的挂单(以挂单价成交)
+func (ex *Exchange) matchInstrument(instrumentID string) {
+	tick, ok := ex.ticks[instrumentID]
+	if !ok {
+		return
+	}
+	liq := &liquidity{ask: tick.AskVolume1, bid: tick.BidVolume1}
+	for _, o := range ex.orders {
+		if o.instrument == instrumentID && o.isWorking() {
+			ex.match(o, tick, liq, true)
+		}
+	}
+}
+
+// match 以对价撮合; passive 为挂单(以委托价成交)
+func (ex *Exchange) match(o *order, tick *goctp.TickField, liq *liquidity, passive bool) {
+	if status, ok := ex.status[o.instrument]; ok && status != goctp.InstrumentStatusContinous {
+		return
+	}
+	var px float64
+	var avail *int
+	if o.direction == ctp.THOST_FTDC_D_Buy {
+		px, avail = tick.AskPrice1, &liq.ask
+		if !goctp.ValidPrice(px) {
+			px = tick.LastPrice
+		}
+		if !goctp.ValidPrice(px) || (o.priceType != ctp.THOST_FTDC_OPT_AnyPrice && o.price < px-1e-8) {
+			return
+		}
+	} else {
+		px, avail = tick.BidPrice1, &liq.bid
+		if !goctp.ValidPrice(px) {
+			px = tick.LastPrice
+		}
+		if !goctp.ValidPrice(px) || (o.priceType != ctp.THOST_FTDC_OPT_AnyPrice && o.price > px+1e-8) {
+			return
+		}
+	}
+	if *avail < 0 { // 本笔行情的对手量已用完
+		return
+	}
+	volume := o.left()
+	if *avail > 0 && volume > *avail {
+		volume = *avail
+	}
+	if volume <= 0 || (o.volCond == ctp.THOST_FTDC_VC_CV && volume < o.left()) {
+		return
+	}
+	if *avail > 0 {
+		*avail -= volume
+		if *avail == 0 {
+			*avail = -1
+		}
+	}
+	if passive && o.priceType != ctp.THOST_FTDC_OPT_AnyPrice {
+		px = o.price
+	}
+	ex.fill(o, px, volume)
+}
+
+// fill 成交: 更新持仓/资金, 回报委托与成交
+func (ex *Exchange) fill(o *order, price float64, volume int) {
+	mult := ex.multiple(o.instrument)
+	commission := ex.Commission * float64(volume)
+	acc := ex.accounts[o.investor]
+	if o.offset == ctp.THOST_FTDC_OF_Open {
+		p := ex.getPosition(o.investor, o.instrument, openDirection(o.direction), o.hedge)
+		p.td += volume
+		p.openCost += price * float64(volume) * mult
+		p.openVolume += volume
+		p.commission += commission
+	} else {
+		p := ex.getPosition(o.investor, o.instrument, closeDirection(o.direction), o.hedge)
+		avg := 0.0
+		if p.yd+p.td > 0 {
+			avg = p.openCost / float64(p.yd+p.td)
+		}
+		yd := volume
+		if yd > o.ydFrozen {
+			yd = o.ydFrozen
+		}
+		td := volume - yd
+		o.ydFrozen -= yd
+		o.tdFrozen -= td
+		p.ydFrozen -= yd
+		p.tdFrozen -= td
+		p.yd -= yd
+		p.td -= td
+		p.openCost -= avg * float64(volume)
+		profit := price*float64(volume)*mult - avg*float64(volume)
+		if p.direction == ctp.THOST_FTDC_PD_Short {
+			profit = -profit
+		}
+		p.closeVolume += volume
+		p.closeProfit += profit
+		p.commission += commission
+		if acc != nil {
+			acc.closeProfit += profit
+		}
+	}
+	if acc != nil {
+		acc.commission += commission
+	}
+	o.traded += volume
+	f := &o.field
+	f.VolumeTraded = ctp.TThostFtdcVolumeType(o.traded)
+	f.VolumeTotal = ctp.TThostFtdcVolumeType(o.left())
+	copy(f.UpdateTime[:], ex.now())
+	if o.left() == 0 {
+		f.OrderStatus = ctp.THOST_FTDC_OST_AllTraded
+		copyGBK(f.StatusMsg[:], "全部成交")
+	} else {
+		f.OrderStatus = ctp.THOST_FTDC_OST_PartTradedQueueing
+		copyGBK(f.StatusMsg[:], "部分成交")
+	}
+	ex.tradeID++
+	trade := &ctp.CThostFtdcTradeField{
+		BrokerID:     f.BrokerID,
+		InvestorID:   f.InvestorID,
+		OrderRef:     f.OrderRef,
+		UserID:       f.UserID,
+		ExchangeID:   f.ExchangeID,
+		Direction:    f.Direction,
+		OrderSysID:   f.OrderSysID,
+		OffsetFlag:   ctp.TThostFtdcOffsetFlagType(o.offset),
+		HedgeFlag:    ctp.TThostFtdcHedgeFlagType(o.hedge),
+		Price:        ctp.TThostFtdcPriceType(price),
+		Volume:       ctp.TThostFtdcVolumeType(volume),
+		InstrumentID: f.InstrumentID,
+	}
+	copy(trade.TradeID[:], fmt.Sprintf("%d", ex.tradeID))
+	copy(trade.TradeDate[:], ex.TradingDay)
+	copy(trade.TradeTime[:], ex.now())
+	copy(trade.TradingDay[:], ex.TradingDay)
+	ex.trades = append(ex.trades, trade)
+	ex.rtnOrder(o)
+	var tf = *trade
+	ex.notify(o.investor, func(s *Trade) {
+		s.post(func() { s.HFTrade.RtnTrade(&tf) })
+	})
+}
+
+func (ex *Exchange) rtnOrder(o *order) {
+	var f = o.field
+	ex.notify(o.investor, func(s *Trade) {
+		s.post(func() { s.HFTrade.RtnOrder(&f) })
+	})
+}
+
+// ---------------- 查询 ----------------
+
+func (ex *Exchange) instrumentFields() []ctp.CThostFtdcInstrumentField {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	fields := make([]ctp.CThostFtdcInstrumentField, 0, len(ex.instruments))
+	for _, inst := range ex.instruments {
+		f := ctp.CThostFtdcInstrumentField{
+			ProductClass:           ctp.TThostFtdcProductClassType(inst.ProductClass),
+			MaxMarketOrderVolume:   ctp.TThostFtdcVolumeType(inst.MaxMarketOrderVolume),
+			MinMarketOrderVolume:   ctp.TThostFtdcVolumeType(inst.MinMarketOrderVolume),
+			MaxLimitOrderVolume:    ctp.TThostFtdcVolumeType(inst.MaxLimitOrderVolume),
+			MinLimitOrderVolume:    ctp.TThostFtdcVolumeType(inst.MinLimitOrderVolume),
+			VolumeMultiple:         ctp.TThostFtdcVolumeMultipleType(inst.VolumeMultiple),
+			PriceTick:              ctp.TThostFtdcPriceType(inst.PriceTick),
+			PositionType:           ctp.TThostFtdcPositionTypeType(inst.PositionType),
+			StrikePrice:            ctp.TThostFtdcPriceType(inst.StrikePrice),
+			OptionsType:            ctp.TThostFtdcOptionsTypeType(inst.OptionsType),
+			UnderlyingMultiple:     ctp.TThostFtdcUnderlyingMultipleType(inst.UnderlyingMultiple),
+			CombinationType:        ctp.TThostFtdcCombinationTypeType(inst.CombinationType),
+			IsTrading:              1,
+			LongMarginRatio:        ctp.TThostFtdcRatioType(ex.MarginRatio),
+			ShortMarginRatio:       ctp.TThostFtdcRatioType(ex.MarginRatio),
+			MaxMarginSideAlgorithm: '0',
+		}
+		if inst.UseMaxMarginSideAlgorithm {
+			f.MaxMarginSideAlgorithm = '1'
+		}
+		copy(f.InstrumentID[:], inst.InstrumentID)
+		copy(f.ExchangeID[:], inst.ExchangeID)
+		copy(f.ProductID[:], inst.ProductID)
+		copy(f.UnderlyingInstrID[:], inst.UnderlyingInstrID)
+		copy(f.ExpireDate[:], inst.ExpireDate)
+		copy(f.StartDelivDate[:], inst.StartDelivDate)
+		copy(f.EndDelivDate[:], inst.EndDelivDate)
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func (ex *Exchange) depthField(instrumentID string) (ctp.CThostFtdcDepthMarketDataField, bool) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	f := ctp.CThostFtdcDepthMarketDataField{}
+	tick, ok := ex.ticks[instrumentID]
+	if !ok {
+		return f, false
+	}
+	copy(f.TradingDay[:], ex.TradingDay)
+	copy(f.InstrumentID[:], tick.InstrumentID)
+	copy(f.ExchangeID[:], tick.ExchangeID)
+	copy(f.UpdateTime[:], tick.UpdateTime)
+	copy(f.ActionDay[:], tick.ActionDay)
+	f.LastPrice = ctp.TThostFtdcPriceType(tick.LastPrice)
+	f.UpperLimitPrice = ctp.TThostFtdcPriceType(tick.UpperLimitPrice)
+	f.LowerLimitPrice = ctp.TThostFtdcPriceType(tick.LowerLimitPrice)
+	f.BidPrice1 = ctp.TThostFtdcPriceType(tick.BidPrice1)
+	f.AskPrice1 = ctp.TThostFtdcPriceType(tick.AskPrice1)
+	return f, true
+}
+
+func (ex *Exchange) accountField(investor string) ctp.CThostFtdcTradingAccountField {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	f := ctp.CThostFtdcTradingAccountField{}
+	copy(f.AccountID[:], investor)
+	copy(f.CurrencyID[:], "CNY")
+	acc, ok := ex.accounts[investor]
+	if !ok {
+		return f
+	}
+	margin, posiProfit := ex.positionMargin(investor)
+	frozen := ex.frozenMargin(investor)
+	f.PreBalance = ctp.TThostFtdcMoneyType(acc.preBalance)
+	f.Deposit = ctp.TThostFtdcMoneyType(acc.deposit)
+	f.Withdraw = ctp.TThostFtdcMoneyType(acc.withdraw)
+	f.CurrMargin = ctp.TThostFtdcMoneyType(margin)
+	f.ExchangeMargin = ctp.TThostFtdcMoneyType(margin)
+	f.FrozenMargin = ctp.TThostFtdcMoneyType(frozen)
+	f.Commission = ctp.TThostFtdcMoneyType(acc.commission)
+	f.CloseProfit = ctp.TThostFtdcMoneyType(acc.closeProfit)
+	f.PositionProfit = ctp.TThostFtdcMoneyType(posiProfit)
+	f.Balance = ctp.TThostFtdcMoneyType(ex.balance(acc))
+	f.Available = ctp.TThostFtdcMoneyType(ex.available(acc))
+	f.WithdrawQuota = f.Available
+	return f
+}
+
+func (ex *Exchange) positionFields(investor string) []ctp.CThostFtdcInvestorPositionField {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	fields := make([]ctp.CThostFtdcInvestorPositionField, 0)
+	for _, p := range ex.positions {
+		if p.investor != investor || (p.yd+p.td == 0 && p.ydInit == 0 && p.openVolume == 0) {
+			continue
+		}
+		mult := ex.multiple(p.instrument)
+		last := ex.lastPrice(p)
+		f := ctp.CThostFtdcInvestorPositionField{
+			PosiDirection:      ctp.TThostFtdcPosiDirectionType(p.direction),
+			HedgeFlag:          ctp.TThostFtdcHedgeFlagType(p.hedge),
+			PositionDate:       ctp.THOST_FTDC_PSD_Today,
+			YdPosition:         ctp.TThostFtdcVolumeType(p.ydInit),
+			Position:           ctp.TThostFtdcVolumeType(p.yd + p.td),
+			TodayPosition:      ctp.TThostFtdcVolumeType(p.td),
+			OpenVolume:         ctp.TThostFtdcVolumeType(p.openVolume),
+			CloseVolume:        ctp.TThostFtdcVolumeType(p.closeVolume),
+			OpenCost:           ctp.TThostFtdcMoneyType(p.openCost),
+			PositionCost:       ctp.TThostFtdcMoneyType(p.openCost),
+			UseMargin:          ctp.TThostFtdcMoneyType(last * float64(p.yd+p.td) * mult * ex.MarginRatio),
+			ExchangeMargin:     ctp.TThostFtdcMoneyType(last * float64(p.yd+p.td) * mult * ex.MarginRatio),
+			CloseProfit:        ctp.TThostFtdcMoneyType(p.closeProfit),
+			CloseProfitByDate:  ctp.TThostFtdcMoneyType(p.closeProfit),
+			CloseProfitByTrade: ctp.TThostFtdcMoneyType(p.closeProfit),
+			Commission:         ctp.TThostFtdcMoneyType(p.commission),
+			PositionProfit:     ctp.TThostFtdcMoneyType(ex.positionProfit(p)),
+			SettlementPrice:    ctp.TThostFtdcPriceType(last),
+		}
+		// 多头持仓被卖平冻结记为 ShortFrozen, 空头持仓被买平冻结记为 LongFrozen
+		if p.direction == ctp.THOST_FTDC_PD_Long {
+			f.ShortFrozen = ctp.TThostFtdcVolumeType(p.ydFrozen + p.tdFrozen)
+		} else {
+			f.LongFrozen = ctp.TThostFtdcVolumeType(p.ydFrozen + p.tdFrozen)
+		}
+		copy(f.InvestorID[:], p.investor)
+		copy(f.InstrumentID[:], p.instrument)
+		if inst, ok := ex.instruments[p.instrument]; ok {
+			copy(f.ExchangeID[:], inst.ExchangeID)
+		}
+		copy(f.TradingDay[:], ex.TradingDay)
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func (ex *Exchange) orderFields(investor string) []ctp.CThostFtdcOrderField {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	fields := make([]ctp.CThostFtdcOrderField, 0)
+	for _, o := range ex.orders {
+		if o.investor == investor {
+			fields = append(fields, o.field)
+		}
+	}
+	return fields
+}
+
+func (ex *Exchange) tradeFields(investor string) []ctp.CThostFtdcTradeField {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	fields := make([]ctp.CThostFtdcTradeField, 0)
+	for _, f := range ex.trades {
+		if goctp.Bytes2String(f.InvestorID[:]) == investor {
+			fields = append(fields, *f)
+		}
+	}
+	return fields
+}
+
+// transfer 银期转帐(amount>0 入金)
+func (ex *Exchange) transfer(investor string, amount float64) (errID int) {
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+	acc, ok := ex.accounts[investor]
+	if !ok {
+		return errInvalidLogin
+	}
+	if amount >= 0 {
+		acc.deposit += amount
+		return 0
+	}
+	if ex.available(acc) < -amount {
+		return errInsufficientMoney
+	}
+	acc.withdraw -= amount
+	return 0
+}
+
+// ---------------- 计算 ----------------
+
+func (ex *Exchange) statusField(instrumentID string) ctp.CThostFtdcInstrumentStatusField {
+	f := ctp.CThostFtdcInstrumentStatusField{
+		InstrumentStatus: ctp.TThostFtdcInstrumentStatusType(ex.status[instrumentID]),
+	}
+	copy(f.InstrumentID[:], instrumentID)
+	if inst, ok := ex.instruments[instrumentID]; ok {
+		copy(f.ExchangeID[:], inst.ExchangeID)
+	}
+	copy(f.EnterTime[:], ex.now())
+	return f
+}
+
+func (ex *Exchange) now() string {
+	if len(ex.updateTime) > 0 {
+		return ex.updateTime
+	}
+	return time.Now().Local().Format("15:04:05")
+}
+
+func (ex *Exchange) multiple(instrumentID string) float64 {
+	if inst, ok := ex.instruments[instrumentID]; ok && inst.VolumeMultiple > 0 {
+		return float64(inst.VolumeMultiple)
+	}
+	return 1
+}
+
+func (ex *Exchange) getPosition(investor, instrument string, direction, hedge byte) *position {
+	key := positionKey(investor, instrument, direction, hedge)
+	p, ok := ex.positions[key]
+	if !ok {
+		p = &position{investor: investor, instrument: instrument, direction: direction, hedge: hedge}
+		ex.positions[key] = p
+	}
+	return p
+}
+
+// lastPrice 最新价, 无行情时取持仓均价
+func (ex *Exchange) lastPrice(p *position) float64 {
+	if tick, ok := ex.ticks[p.instrument]; ok && goctp.ValidPrice(tick.LastPrice) {
+		return tick.LastPrice
+	}
+	if p.yd+p.td > 0 {
+		return p.openCost / float64(p.yd+p.td) / ex.multiple(p.instrument)
+	}
+	return 0
+}
+
+func (ex *Exchange) positionProfit(p *position) float64 {
+	profit := ex.lastPrice(p)*float64(p.yd+p.td)*ex.multiple(p.instrument) - p.openCost
+	if p.direction == ctp.THOST_FTDC_PD_Short {
+		profit = -profit
+	}
+	return profit
+}
+
+func (ex *Exchange) positionMargin(investor string) (margin, profit float64) {
+	for _, p := range ex.positions {
+		if p.investor == investor {
+			margin += ex.lastPrice(p) * float64(p.yd+p.td) * ex.multiple(p.instrument) * ex.MarginRatio
+			profit += ex.positionProfit(p)
+		}
+	}
+	return
+}
+
+// orderMargin 开仓委托冻结的保证金
+func (ex *Exchange) orderMargin(o *order) float64 {
+	price := o.price
+	if o.priceType == ctp.THOST_FTDC_OPT_AnyPrice {
+		price = 0
+		if tick, ok := ex.ticks[o.instrument]; ok {
+			price = tick.LastPrice
+		}
+	}
+	return price * float64(o.left()) * ex.multiple(o.instrument) * ex.MarginRatio
+}
+
+func (ex *Exchange) frozenMargin(investor string) (frozen float64) {
+	for _, o := range ex.orders {
+		if o.investor == investor && o.offset == ctp.THOST_FTDC_OF_Open && o.isWorking() {
+			frozen += ex.orderMargin(o)
+		}
+	}
+	return
+}
+
+func (ex *Exchange) balance(acc *account) float64 {
+	_, profit := ex.positionMargin(acc.investor)
+	return acc.preBalance + acc.deposit - acc.withdraw + acc.closeProfit + profit - acc.commission
+}
+
+func (ex *Exchange) available(acc *account) float64 {
+	margin, _ := ex.positionMargin(acc.investor)
+	return ex.balance(acc) - margin - ex.frozenMargin(acc.investor)
+}
+
+func positionKey(investor, instrument string, direction, hedge byte) string {
+	return fmt.Sprintf("%s_%s_%c_%c", investor, instrument, direction, hedge)
+}
+
+// openDirection 开仓委托对应的持仓方向
+func openDirection(direction byte) byte {
+	if direction == ctp.THOST_FTDC_D_Buy {
+		return ctp.THOST_FTDC_PD_Long
+	}
+	return ctp.THOST_FTDC_PD_Short
+}
+
+// closeDirection 平仓委托对应的持仓方向
+func closeDirection(direction byte) byte {
+	if direction == ctp.THOST_FTDC_D_Buy {
+		return ctp.THOST_FTDC_PD_Short
+	}
+	return ctp.THOST_FTDC_PD_Long
+}
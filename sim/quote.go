@@ -0,0 +1,230 @@
+package sim
+
+import (
+	"sync"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// Quote 模拟行情接口: 按时间回放行情, 订阅的合约推送 RtnDepthMarketData; 行情同时送 Exchange 撮合
+type Quote struct {
+	goctp.HFQuote
+	spi
+	ex *Exchange
+
+	Speed float64 // 回放速度: 1 实时, >1 加速, <=0 不等待(上一笔处理完即推送下一笔)
+
+	mu       sync.Mutex
+	ticks    []*goctp.TickField
+	pos      int             // 回放位置, 断开重连后继续
+	known    map[string]bool // 行情中的合约
+	subs     map[string]bool
+	running  bool
+	finish   sync.Once
+	chFinish chan struct{}
+}
+
+// NewQuote 实例化, ex 可为 nil(只回放行情)
+func NewQuote(ex *Exchange) *Quote {
+	q := new(Quote)
+	q.ex = ex
+	q.Speed = 1
+	q.known = make(map[string]bool)
+	q.subs = make(map[string]bool)
+	q.chFinish = make(chan struct{})
+
+	// 主调函数封装
+	q.HFQuote.ReqConnect = func(addr string) {
+		q.start()
+		q.post(q.HFQuote.FrontConnected)
+	}
+	q.HFQuote.ReleaseAPI = func() {
+		q.stop()
+		q.mu.Lock()
+		q.running = false
+		q.mu.Unlock()
+	}
+	q.HFQuote.ReqUserLogin = func(f *ctp.CThostFtdcReqUserLoginField, i int) {
+		login := ctp.CThostFtdcRspUserLoginField{
+			BrokerID: f.BrokerID,
+			UserID:   f.UserID,
+		}
+		copy(login.TradingDay[:], q.tradingDay())
+		copy(login.LoginTime[:], time.Now().Local().Format("15:04:05"))
+		copy(login.SystemName[:], "sim")
+		copy(login.SysVersion[:], Version)
+		q.post(func() { q.HFQuote.RspUserLogin(&login, rspInfo(0)) })
+	}
+	q.HFQuote.ReqSubMarketData = func(instruments ...string) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for _, inst := range instruments {
+			f := ctp.CThostFtdcSpecificInstrumentField{}
+			copy(f.InstrumentID[:], inst)
+			errID := 0
+			if q.known[inst] || (q.ex != nil && q.ex.hasInstrument(inst)) {
+				q.subs[inst] = true
+			} else { // 行情与交易所中都没有的合约
+				errID = errInstrumentNotFound
+			}
+			q.post(func() { q.HFQuote.RspSubMarketData(&f, rspInfo(errID)) })
+		}
+		if !q.running { // 首次订阅时开始回放
+			q.running = true
+			go q.replay(q.done())
+		}
+	}
+	q.HFQuote.ReqUnSubMarketData = func(instruments ...string) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for _, inst := range instruments {
+			delete(q.subs, inst)
+			f := ctp.CThostFtdcSpecificInstrumentField{}
+			copy(f.InstrumentID[:], inst)
+			q.post(func() { q.HFQuote.RspUnSubMarketData(&f, rspInfo(0)) })
+		}
+	}
+
+	q.HFQuote.Init() // 初始化
+	return q
+}
+
+// Disconnect 模拟断线: 响应 FrontDisConnected, 前置清除订阅, 随后自动重连(响应 FrontConnected)
+func (q *Quote) Disconnect() {
+	q.mu.Lock()
+	q.subs = make(map[string]bool)
+	q.mu.Unlock()
+	q.post(func() { q.HFQuote.FrontDisConnected(4097) })
+	q.post(q.HFQuote.FrontConnected)
+}
+
+// Load 加载行情文件(recorder 记录的 csv 或二进制), 多个文件按时间合并
+func (q *Quote) Load(files ...string) error {
+	for _, file := range files {
+		ticks, err := ReadTicks(file)
+		if err != nil {
+			return err
+		}
+		q.AddTicks(ticks...)
+	}
+	return nil
+}
+
+// AddTicks 添加待回放的行情(回放开始前调用)
+func (q *Quote) AddTicks(ticks ...*goctp.TickField) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ticks = append(q.ticks, ticks...)
+	for _, tick := range ticks {
+		q.known[tick.InstrumentID] = true
+	}
+	sortTicks(q.ticks[q.pos:])
+}
+
+// Done 回放结束
+func (q *Quote) Done() <-chan struct{} {
+	return q.chFinish
+}
+
+func (q *Quote) tradingDay() string {
+	if q.ex != nil {
+		return q.ex.TradingDay
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.ticks) > 0 {
+		return q.ticks[0].TradingDay
+	}
+	return time.Now().Local().Format("20060102")
+}
+
+// replay 回放: 按 Speed 等待至行情时间, 送交易所撮合, 订阅的合约推送并等待回调执行完成
+func (q *Quote) replay(chDone chan struct{}) {
+	var base, start time.Time // 首笔行情时间 / 开始回放的时间
+	for {
+		select {
+		case <-chDone: // 已断开
+			return
+		default:
+		}
+		q.mu.Lock()
+		if q.pos >= len(q.ticks) {
+			q.mu.Unlock()
+			q.finish.Do(func() { close(q.chFinish) })
+			return
+		}
+		tick := q.ticks[q.pos]
+		q.pos++
+		sub, speed := q.subs[tick.InstrumentID], q.Speed
+		q.mu.Unlock()
+
+		if tt := tickTime(tick); speed > 0 && !tt.IsZero() {
+			if base.IsZero() {
+				base, start = tt, time.Now()
+			}
+			if wait := time.Until(start.Add(time.Duration(float64(tt.Sub(base)) / speed))); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-chDone:
+					return
+				}
+			}
+		}
+		if q.ex != nil {
+			q.ex.UpdateTick(tick)
+		}
+		if sub {
+			f := depthMarketDataField(tick)
+			if !q.call(func() { q.HFQuote.RtnDepthMarketData(&f) }) {
+				return
+			}
+		}
+	}
+}
+
+func depthMarketDataField(tick *goctp.TickField) ctp.CThostFtdcDepthMarketDataField {
+	f := ctp.CThostFtdcDepthMarketDataField{
+		LastPrice:       ctp.TThostFtdcPriceType(tick.LastPrice),
+		OpenPrice:       ctp.TThostFtdcPriceType(tick.OpenPrice),
+		HighestPrice:    ctp.TThostFtdcPriceType(tick.HighestPrice),
+		LowestPrice:     ctp.TThostFtdcPriceType(tick.LowestPrice),
+		Volume:          ctp.TThostFtdcVolumeType(tick.Volume),
+		Turnover:        ctp.TThostFtdcMoneyType(tick.Turnover),
+		OpenInterest:    ctp.TThostFtdcLargeVolumeType(tick.OpenInterest),
+		ClosePrice:      ctp.TThostFtdcPriceType(tick.ClosePrice),
+		SettlementPrice: ctp.TThostFtdcPriceType(tick.SettlementPrice),
+		UpperLimitPrice: ctp.TThostFtdcPriceType(tick.UpperLimitPrice),
+		LowerLimitPrice: ctp.TThostFtdcPriceType(tick.LowerLimitPrice),
+		CurrDelta:       ctp.TThostFtdcRatioType(tick.CurrDelta),
+		UpdateMillisec:  ctp.TThostFtdcMillisecType(tick.UpdateMillisec),
+		BidPrice1:       ctp.TThostFtdcPriceType(tick.BidPrice1),
+		BidVolume1:      ctp.TThostFtdcVolumeType(tick.BidVolume1),
+		AskPrice1:       ctp.TThostFtdcPriceType(tick.AskPrice1),
+		AskVolume1:      ctp.TThostFtdcVolumeType(tick.AskVolume1),
+		BidPrice2:       ctp.TThostFtdcPriceType(tick.BidPrice2),
+		BidVolume2:      ctp.TThostFtdcVolumeType(tick.BidVolume2),
+		AskPrice2:       ctp.TThostFtdcPriceType(tick.AskPrice2),
+		AskVolume2:      ctp.TThostFtdcVolumeType(tick.AskVolume2),
+		BidPrice3:       ctp.TThostFtdcPriceType(tick.BidPrice3),
+		BidVolume3:      ctp.TThostFtdcVolumeType(tick.BidVolume3),
+		AskPrice3:       ctp.TThostFtdcPriceType(tick.AskPrice3),
+		AskVolume3:      ctp.TThostFtdcVolumeType(tick.AskVolume3),
+		BidPrice4:       ctp.TThostFtdcPriceType(tick.BidPrice4),
+		BidVolume4:      ctp.TThostFtdcVolumeType(tick.BidVolume4),
+		AskPrice4:       ctp.TThostFtdcPriceType(tick.AskPrice4),
+		AskVolume4:      ctp.TThostFtdcVolumeType(tick.AskVolume4),
+		BidPrice5:       ctp.TThostFtdcPriceType(tick.BidPrice5),
+		BidVolume5:      ctp.TThostFtdcVolumeType(tick.BidVolume5),
+		AskPrice5:       ctp.TThostFtdcPriceType(tick.AskPrice5),
+		AskVolume5:      ctp.TThostFtdcVolumeType(tick.AskVolume5),
+		AveragePrice:    ctp.TThostFtdcPriceType(tick.AveragePrice),
+	}
+	copy(f.TradingDay[:], tick.TradingDay)
+	copy(f.InstrumentID[:], tick.InstrumentID)
+	copy(f.ExchangeID[:], tick.ExchangeID)
+	copy(f.UpdateTime[:], tick.UpdateTime)
+	copy(f.ActionDay[:], tick.ActionDay)
+	return f
+}
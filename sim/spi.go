@@ -0,0 +1,113 @@
+package sim
+
+import (
+	"sync"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// spi 模拟 CTP 的回调线程: 响应按提交顺序在同一协程中执行
+type spi struct {
+	mu       sync.Mutex
+	queue    []func()
+	chSignal chan struct{}
+	chDone   chan struct{}
+}
+
+// start 启动回调线程(ReqConnect 时调用)
+func (s *spi) start() {
+	s.mu.Lock()
+	s.queue = nil
+	s.chSignal = make(chan struct{}, 1)
+	s.chDone = make(chan struct{})
+	s.mu.Unlock()
+	go s.run(s.chSignal, s.chDone)
+}
+
+// stop 停止回调线程, 未执行的响应丢弃
+func (s *spi) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chDone != nil {
+		close(s.chDone)
+		s.chDone = nil
+	}
+	s.queue = nil
+}
+
+// post 提交响应. 队列不限长度, 在回调中再发请求不会阻塞
+func (s *spi) post(fn func()) {
+	s.mu.Lock()
+	if s.chDone == nil { // 未连接或已释放
+		s.mu.Unlock()
+		return
+	}
+	s.queue = append(s.queue, fn)
+	chSignal := s.chSignal
+	s.mu.Unlock()
+	select {
+	case chSignal <- struct{}{}:
+	default:
+	}
+}
+
+// done 回调线程停止的信号(未启动时为 nil)
+func (s *spi) done() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chDone
+}
+
+// call 提交响应并等待其执行完成(回放行情时用于限流). 回调线程停止时返回 false
+func (s *spi) call(fn func()) bool {
+	chDone := s.done()
+	if chDone == nil {
+		return false
+	}
+	chFinish := make(chan struct{})
+	s.post(func() {
+		fn()
+		close(chFinish)
+	})
+	select {
+	case <-chFinish:
+		return true
+	case <-chDone:
+		return false
+	}
+}
+
+func (s *spi) run(chSignal, chDone chan struct{}) {
+	for {
+		select {
+		case <-chDone:
+			return
+		case <-chSignal:
+		}
+		for {
+			select {
+			case <-chDone:
+				return
+			default:
+			}
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			fn := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			fn()
+		}
+	}
+}
+
+// copyGBK 按 CTP 编码(GB18030)写入中文, 与 goctp.Bytes2String 对应
+func copyGBK(dst []byte, s string) {
+	bs, err := simplifiedchinese.GB18030.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		bs = []byte(s)
+	}
+	copy(dst, bs)
+}
@@ -0,0 +1,48 @@
+package sim
+
+import (
+	"sort"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+	"gitee.com/haifengat/goctp/recorder"
+)
+
+// ReadTicks 读取行情文件(recorder 记录的 csv 或二进制格式)
+func ReadTicks(file string) ([]*goctp.TickField, error) {
+	return recorder.ReadFile(file)
+}
+
+// tickTime 行情时间. 以 TradingDay 为基准, 18 点之后的夜盘计为前一日,
+// 不依赖各交易所含义不一的 ActionDay(大商所夜盘 ActionDay 为交易日)
+func tickTime(tick *goctp.TickField) time.Time {
+	day := tick.TradingDay
+	if len(day) == 0 {
+		day = tick.ActionDay
+	}
+	t, err := time.ParseInLocation("2006010215:04:05", day+tick.UpdateTime, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	if t.Hour() >= 18 && len(tick.TradingDay) > 0 {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t.Add(time.Duration(tick.UpdateMillisec) * time.Millisecond)
+}
+
+// sortTicks 多个文件的行情按时间合并(同一时间保持原顺序), 每笔行情的时间只解析一次
+func sortTicks(ticks []*goctp.TickField) {
+	keyed := make([]struct {
+		t    time.Time
+		tick *goctp.TickField
+	}, len(ticks))
+	for i, tick := range ticks {
+		keyed[i].t, keyed[i].tick = tickTime(tick), tick
+	}
+	sort.SliceStable(keyed, func(i, j int) bool {
+		return keyed[i].t.Before(keyed[j].t)
+	})
+	for i := range keyed {
+		ticks[i] = keyed[i].tick
+	}
+}
@@ -0,0 +1,157 @@
+package sim
+
+import (
+	"gitee.com/haifengat/goctp"
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// Version 模拟接口版本号(>= v6.5.1 时登录后以 ReqQryClassifiedInstrument 查合约)
+const Version = "v6.6.8_sim"
+
+// Trade 模拟交易接口: 以 Exchange 实现 HFTrade 的主调函数
+type Trade struct {
+	goctp.HFTrade
+	spi
+	ex *Exchange
+
+	frontID    int
+	sessionID  int
+	investorID string
+}
+
+// NewTrade 实例化
+func NewTrade(ex *Exchange) *Trade {
+	t := new(Trade)
+	t.ex = ex
+	t.frontID = 1
+
+	// 主调函数封装
+	t.HFTrade.GetVersion = func() string {
+		return Version
+	}
+	t.HFTrade.ReqConnect = func(addr string) {
+		t.start()
+		t.post(t.HFTrade.FrontConnected)
+	}
+	t.HFTrade.ReleaseAPI = func() {
+		t.ex.logout(t)
+		t.stop()
+	}
+	t.HFTrade.ReqAuthenticate = func(f *ctp.CThostFtdcReqAuthenticateField, i int) {
+		t.post(func() { t.HFTrade.RspAuthenticate(rspInfo(0), i) })
+	}
+	t.HFTrade.ReqUserLogin = func(f *ctp.CThostFtdcReqUserLoginField, i int) {
+		sessionID, errID := t.ex.login(t, goctp.Bytes2String(f.UserID[:]))
+		login := ctp.CThostFtdcRspUserLoginField{
+			BrokerID:  f.BrokerID,
+			UserID:    f.UserID,
+			FrontID:   ctp.TThostFtdcFrontIDType(t.frontID),
+			SessionID: ctp.TThostFtdcSessionIDType(sessionID),
+		}
+		copy(login.TradingDay[:], t.ex.TradingDay)
+		copy(login.LoginTime[:], t.ex.now())
+		copy(login.MaxOrderRef[:], "1")
+		copy(login.SystemName[:], "sim")
+		copy(login.SysVersion[:], Version)
+		t.post(func() { t.HFTrade.RspUserLogin(&login, rspInfo(errID), i) })
+		if errID == 0 {
+			t.ex.publish(t)
+		}
+	}
+	t.HFTrade.ReqSettlementInfoConfirm = func(f *ctp.CThostFtdcSettlementInfoConfirmField, i int) {
+		t.post(t.HFTrade.RspSettlementInfoConfirm)
+	}
+	t.HFTrade.ReqQryInstrument = func(f *ctp.CThostFtdcQryInstrumentField, i int) {
+		t.rspQryInstrument(i)
+	}
+	t.HFTrade.ReqQryClassifiedInstrument = func(f *ctp.CThostFtdcQryClassifiedInstrumentField, i int) {
+		t.rspQryInstrument(i)
+	}
+	t.HFTrade.ReqQryTradingAccount = func(f *ctp.CThostFtdcQryTradingAccountField, i int) {
+		field := t.ex.accountField(t.investorID)
+		copy(field.BrokerID[:], t.BrokerID)
+		t.post(func() { t.HFTrade.RspQryTradingAccount(&field, true) })
+	}
+	t.HFTrade.ReqQryInvestorPosition = func(f *ctp.CThostFtdcQryInvestorPositionField, i int) {
+		fields := t.ex.positionFields(t.investorID)
+		if len(fields) == 0 { // 无持仓: 空响应
+			t.post(func() { t.HFTrade.RspQryInvestorPosition(&ctp.CThostFtdcInvestorPositionField{}, true) })
+			return
+		}
+		for i := range fields {
+			field, last := fields[i], i == len(fields)-1
+			copy(field.BrokerID[:], t.BrokerID)
+			t.post(func() { t.HFTrade.RspQryInvestorPosition(&field, last) })
+		}
+	}
+	t.HFTrade.ReqQryInvestor = func(f *ctp.CThostFtdcQryInvestorField, i int) {
+		field := ctp.CThostFtdcInvestorField{BrokerID: f.BrokerID, IsActive: 1}
+		copy(field.InvestorID[:], t.investorID)
+		t.post(func() { t.HFTrade.RspQryInvestor(&field, true) })
+	}
+	t.HFTrade.ReqQryOrder = func(f *ctp.CThostFtdcQryOrderField, i int) {
+		fields := t.ex.orderFields(t.investorID)
+		if len(fields) == 0 {
+			t.post(func() { t.HFTrade.RspQryOrder(&ctp.CThostFtdcOrderField{}, true) })
+			return
+		}
+		for i := range fields {
+			field, last := fields[i], i == len(fields)-1
+			t.post(func() { t.HFTrade.RspQryOrder(&field, last) })
+		}
+	}
+	t.HFTrade.ReqQryTrade = func(f *ctp.CThostFtdcQryTradeField, i int) {
+		fields := t.ex.tradeFields(t.investorID)
+		if len(fields) == 0 {
+			t.post(func() { t.HFTrade.RspQryTrade(&ctp.CThostFtdcTradeField{}, true) })
+			return
+		}
+		for i := range fields {
+			field, last := fields[i], i == len(fields)-1
+			t.post(func() { t.HFTrade.RspQryTrade(&field, last) })
+		}
+	}
+	t.HFTrade.ReqQryDepth = func(f *ctp.CThostFtdcQryDepthMarketDataField, i int) {
+		field, _ := t.ex.depthField(goctp.Bytes2String(f.InstrumentID[:]))
+		t.post(func() { t.HFTrade.RspQryDepthMarketData(&field, rspInfo(0), i, true) })
+	}
+	t.HFTrade.ReqOrder = func(f *ctp.CThostFtdcInputOrderField, i int) {
+		t.ex.insertOrder(t, f)
+	}
+	t.HFTrade.ReqAction = func(f *ctp.CThostFtdcInputOrderActionField, i int) {
+		t.ex.cancelOrder(t, f)
+	}
+	t.HFTrade.ReqFromBankToFutureByFuture = func(f *ctp.CThostFtdcReqTransferField, i int) {
+		t.rspTransfer(f, float64(f.TradeAmount), t.HFTrade.RtnFromBankToFutureByFuture)
+	}
+	t.HFTrade.ReqFromFutureToBankByFuture = func(f *ctp.CThostFtdcReqTransferField, i int) {
+		t.rspTransfer(f, -float64(f.TradeAmount), t.HFTrade.RtnFromFutureToBankByFuture)
+	}
+
+	t.HFTrade.Init() // 初始化
+	return t
+}
+
+func (t *Trade) rspQryInstrument(reqID int) {
+	fields := t.ex.instrumentFields()
+	if len(fields) == 0 {
+		t.post(func() { t.HFTrade.RspQryInstrument(nil, rspInfo(0), reqID, true) })
+		return
+	}
+	for i := range fields {
+		field, last := fields[i], i == len(fields)-1
+		t.post(func() { t.HFTrade.RspQryInstrument(&field, rspInfo(0), reqID, last) })
+	}
+}
+
+func (t *Trade) rspTransfer(f *ctp.CThostFtdcReqTransferField, amount float64, rtn func(*ctp.CThostFtdcRspTransferField)) {
+	field := ctp.CThostFtdcRspTransferField{
+		TradeAmount: f.TradeAmount,
+		CurrencyID:  f.CurrencyID,
+	}
+	if errID := t.ex.transfer(t.investorID, amount); errID != 0 {
+		field.ErrorID = ctp.TThostFtdcErrorIDType(errID)
+		copyGBK(field.ErrorMsg[:], errMsgs[errID])
+	}
+	t.post(func() { rtn(&field) })
+}
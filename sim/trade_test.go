@@ -0,0 +1,240 @@
+package sim_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/haifengat/goctp"
+	"gitee.com/haifengat/goctp/sim"
+)
+
+// newTrade 登录模拟交易: rb2305 对手价 3000/3001, 帐户 100 万
+func newTrade(t *testing.T) (*sim.Exchange, *sim.Trade) {
+	ex := sim.NewExchange()
+	ex.AddInstrument(&goctp.InstrumentField{InstrumentID: "rb2305", ProductID: "rb", ExchangeID: "SHFE", VolumeMultiple: 10, PriceTick: 1, MaxLimitOrderVolume: 500})
+	ex.AddAccount("008105", 1000000)
+	ex.UpdateTick(&goctp.TickField{InstrumentID: "rb2305", LastPrice: 3000, BidPrice1: 3000, AskPrice1: 3001, UpperLimitPrice: 3300, LowerLimitPrice: 2700})
+
+	tr := sim.NewTrade(ex)
+	chLogin := make(chan *goctp.RspInfoField, 1)
+	tr.RegOnFrontConnected(func() {
+		tr.ReqLogin("008105", "1", "9999", "", "")
+	})
+	tr.RegOnRspUserLogin(func(login *goctp.RspUserLoginField, info *goctp.RspInfoField) {
+		chLogin <- info
+	})
+	tr.ReqConnect("sim")
+	t.Cleanup(tr.Release)
+	select {
+	case info := <-chLogin:
+		if info.ErrorID != 0 {
+			t.Fatalf("登录失败: %d %s", info.ErrorID, info.ErrorMsg)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("登录超时")
+	}
+	return ex, tr
+}
+
+func TestTradeRoundTrip(t *testing.T) {
+	ex, tr := newTrade(t)
+	if _, ok := tr.Instruments.Load("rb2305"); !ok {
+		t.Fatal("未查询到合约")
+	}
+	chTrade := make(chan *goctp.TradeField, 10)
+	chCancel := make(chan *goctp.OrderField, 10)
+	tr.RegOnRtnTrade(func(field *goctp.TradeField) { chTrade <- field })
+	tr.RegOnRtnCancel(func(field *goctp.OrderField) { chCancel <- field })
+
+	// 以对手价开仓: 立即成交
+	id := tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 3001, 2)
+	select {
+	case trade := <-chTrade:
+		if trade.Price != 3001 || trade.Volume != 2 {
+			t.Fatalf("成交 %g %d, 应为 3001 2", trade.Price, trade.Volume)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("未成交")
+	}
+	o, ok := tr.Orders.Load(id)
+	if !ok || o.(*goctp.OrderField).OrderStatus != goctp.OrderStatusAllTraded {
+		t.Fatalf("委托 %s 应为全部成交", id)
+	}
+	p, ok := tr.Positions.Load(goctp.PositionKey("rb2305", goctp.PosiDirectionLong, goctp.HedgeFlagSpeculation))
+	if !ok || p.(*goctp.PositionField).Position != 2 || p.(*goctp.PositionField).TodayPosition != 2 {
+		t.Fatal("多头今仓应为 2")
+	}
+
+	// 挂单后撤单
+	id = tr.ReqOrderInsert("rb2305", goctp.DirectionSell, goctp.OffsetFlagCloseToday, 3100, 1)
+	time.Sleep(100 * time.Millisecond)
+	if ret := tr.ReqOrderAction(id); ret != 0 {
+		t.Fatalf("撤单返回 %d", ret)
+	}
+	select {
+	case o := <-chCancel:
+		if o.VolumeLeft != 1 {
+			t.Fatalf("撤单剩余 %d, 应为 1", o.VolumeLeft)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("未撤单")
+	}
+
+	// 挂单由行情撮合: 以委托价成交
+	tr.ReqOrderInsert("rb2305", goctp.DirectionSell, goctp.OffsetFlagCloseToday, 3010, 2)
+	time.Sleep(100 * time.Millisecond)
+	ex.UpdateTick(&goctp.TickField{InstrumentID: "rb2305", LastPrice: 3020, BidPrice1: 3020, AskPrice1: 3021, UpperLimitPrice: 3300, LowerLimitPrice: 2700})
+	select {
+	case trade := <-chTrade:
+		if trade.Price != 3010 || trade.OffsetFlag != goctp.OffsetFlagCloseToday {
+			t.Fatalf("成交 %g %c, 应为 3010 平今", trade.Price, trade.OffsetFlag)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("挂单未成交")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if p.(*goctp.PositionField).Position != 0 {
+		t.Fatalf("平仓后持仓 %d, 应为 0", p.(*goctp.PositionField).Position)
+	}
+}
+
+func TestTradeReject(t *testing.T) {
+	_, tr := newTrade(t)
+	chErr := make(chan *goctp.RspInfoField, 10)
+	tr.RegOnErrRtnOrder(func(field *goctp.OrderField, info *goctp.RspInfoField) { chErr <- info })
+
+	tr.ReqOrderInsert("rb2309", goctp.DirectionBuy, goctp.OffsetFlagOpen, 3001, 1)   // 合约不存在
+	tr.ReqOrderInsert("rb2305", goctp.DirectionSell, goctp.OffsetFlagClose, 3000, 1) // 无持仓
+	for i := 0; i < 2; i++ {
+		select {
+		case info := <-chErr:
+			if info.ErrorID == 0 {
+				t.Fatal("错误委托的 ErrorID 为 0")
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("第 %d 笔错误委托未响应", i+1)
+		}
+	}
+}
+
+func TestRiskReject(t *testing.T) {
+	_, tr := newTrade(t)
+	chErr := make(chan *goctp.RspInfoField, 10)
+	tr.RegOnErrRtnOrder(func(field *goctp.OrderField, info *goctp.RspInfoField) { chErr <- info })
+	tr.AddRiskRule(tr.RiskMaxOrderVolume())
+
+	id := tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 3001, 501) // 超过最大下单量 500
+	select {
+	case info := <-chErr:
+		if info.ErrorID == 0 || !strings.HasPrefix(info.ErrorMsg, "风控") {
+			t.Fatalf("风控拒绝: %d %s", info.ErrorID, info.ErrorMsg)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("风控拒绝未响应")
+	}
+	o, ok := tr.Orders.Load(id)
+	if !ok || o.(*goctp.OrderField).OrderStatus != goctp.OrderStatusCanceled || o.(*goctp.OrderField).OrderSubmitStatus != goctp.OrderSubmitStatusInsertRejected {
+		t.Fatalf("委托 %s 应以已撤单(报单被拒绝)保存", id)
+	}
+	if s := tr.OrderStat("rb2305"); s.Orders != 0 || s.Rejects != 0 {
+		t.Fatalf("本地风控拒绝不计入交易所统计: %+v", *s)
+	}
+}
+
+func TestCancelLimit(t *testing.T) {
+	_, tr := newTrade(t)
+	chCancel := make(chan *goctp.OrderField, 10)
+	tr.RegOnRtnCancel(func(field *goctp.OrderField) { chCancel <- field })
+	tr.SetCancelLimit(1)
+
+	first := tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 2900, 1)
+	second := tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 2900, 1)
+	time.Sleep(100 * time.Millisecond)
+	if ret := tr.ReqOrderAction(first); ret != 0 {
+		t.Fatalf("撤单返回 %d", ret)
+	}
+	if ret := tr.ReqOrderAction(second); ret != -2 { // 撤单中的委托计入上限
+		t.Fatalf("撤单数达到上限时返回 %d, 应为 -2", ret)
+	}
+	select {
+	case <-chCancel:
+	case <-time.After(3 * time.Second):
+		t.Fatal("未撤单")
+	}
+	if s := tr.OrderStat("rb2305"); s.Orders != 2 || s.Cancels != 1 {
+		t.Fatalf("委托/撤单数 %d/%d, 应为 2/1", s.Orders, s.Cancels)
+	}
+	if ret := tr.ReqOrderAction(second); ret != -2 {
+		t.Fatalf("撤单数达到上限时返回 %d, 应为 -2", ret)
+	}
+}
+
+func TestKillSwitch(t *testing.T) {
+	_, tr := newTrade(t)
+	tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 3001, 2)
+	tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 2900, 1) // 挂单
+	time.Sleep(200 * time.Millisecond)
+
+	sum := tr.KillSwitch(5 * time.Second)
+	if !sum.Flat || sum.Canceled != 1 || sum.Closed != 2 {
+		t.Fatalf("全部撤单平仓: %+v", *sum)
+	}
+	if len(tr.WorkingOrders()) != 0 {
+		t.Fatal("仍有挂单")
+	}
+	p, ok := tr.Positions.Load(goctp.PositionKey("rb2305", goctp.PosiDirectionLong, goctp.HedgeFlagSpeculation))
+	if !ok || p.(*goctp.PositionField).Position != 0 {
+		t.Fatal("多头持仓应为 0")
+	}
+}
+
+func TestOrderModify(t *testing.T) {
+	_, tr := newTrade(t)
+	chModify := make(chan string, 1)
+	tr.RegOnRtnOrderModify(func(orderID, newOrderID string, info *goctp.RspInfoField) {
+		if info.ErrorID != 0 {
+			t.Errorf("改单失败: %d %s", info.ErrorID, info.ErrorMsg)
+		}
+		chModify <- newOrderID
+	})
+
+	id := tr.ReqOrderInsert("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 2900, 2)
+	time.Sleep(100 * time.Millisecond)
+	if ret := tr.ReqOrderModify(id, 2900, 2); ret != -4 {
+		t.Fatalf("未变化的改单返回 %d, 应为 -4", ret)
+	}
+	if ret := tr.ReqOrderModify(id, 2950, 1); ret != 0 { // 撤单后重新委托
+		t.Fatalf("改单返回 %d", ret)
+	}
+	select {
+	case newID := <-chModify:
+		time.Sleep(100 * time.Millisecond)
+		o, ok := tr.Orders.Load(newID)
+		if !ok || o.(*goctp.OrderField).LimitPrice != 2950 || o.(*goctp.OrderField).VolumeTotalOriginal != 1 {
+			t.Fatalf("新委托 %s 应为 2950 1 手", newID)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("改单未响应")
+	}
+	if o, _ := tr.Orders.Load(id); o.(*goctp.OrderField).OrderStatus != goctp.OrderStatusCanceled {
+		t.Fatal("原委托应已撤销")
+	}
+}
+
+func TestInsertOrderReject(t *testing.T) {
+	_, tr := newTrade(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	o, err := tr.InsertOrder(ctx, goctp.NewOrderRequest("rb2305", goctp.DirectionSell, goctp.OffsetFlagClose, 3000, 1)) // 无持仓
+	if err == nil || err == context.DeadlineExceeded {
+		t.Fatalf("无持仓平仓应返回拒绝错误, 实际 %v", err)
+	}
+	if o == nil || o.OrderStatus != goctp.OrderStatusCanceled {
+		t.Fatal("被拒绝的委托应为已撤单")
+	}
+	if o, err = tr.InsertOrder(ctx, goctp.NewOrderRequest("rb2305", goctp.DirectionBuy, goctp.OffsetFlagOpen, 3001, 1)); err != nil || o.OrderStatus != goctp.OrderStatusAllTraded {
+		t.Fatalf("对手价委托应全部成交: %v", err)
+	}
+}
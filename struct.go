@@ -346,16 +346,26 @@ type OrderField struct {
 	OffsetFlag OffsetFlagType
 	// 组合投机套保标志
 	HedgeFlag HedgeFlagType
+	// 报单价格条件
+	PriceType OrderPriceTypeType
 	// 价格
 	LimitPrice float64
 	// 数量
 	VolumeTotalOriginal int
+	// 有效期类型
+	TimeCondition TimeConditionType
+	// 成交量类型
+	VolumeCondition VolumeConditionType
+	// 最小成交量
+	MinVolume int
 	// 交易所代码
 	ExchangeID string
 	// 报单编号
 	OrderSysID string
 	// 报单状态
 	OrderStatus OrderStatusType
+	// 报单提交状态
+	OrderSubmitStatus OrderSubmitStatusType
 	// 今成交数量
 	VolumeTraded int
 	// 剩余数量
@@ -422,6 +432,278 @@ type InstrumentStatus struct {
 	EnterTime string
 }
 
+// SubscribeField 合约订阅状态
+type SubscribeField struct {
+	// 合约代码
+	InstrumentID string
+	// 订阅状态
+	Status SubscribeStatusType
+	// 错误代码(订阅失败时)
+	ErrorID int
+	// 错误信息(订阅失败时)
+	ErrorMsg string
+}
+
+// ExecOrderField 执行宣告(期权行权/放弃行权)
+type ExecOrderField struct {
+	// 交易帐号
+	InvestorID string
+	// 合约代码
+	InstrumentID string
+	// 执行宣告引用
+	ExecOrderRef string
+	// 交易所代码
+	ExchangeID string
+	// 执行宣告编号
+	ExecOrderSysID string
+	// 执行类型
+	ActionType ExecActionType
+	// 数量
+	Volume int
+	// 执行结果
+	ExecResult ExecResultType
+	// 报单日期
+	InsertDate string
+	// 插入时间
+	InsertTime string
+	// 撤销时间
+	CancelTime string
+	// 前置编号
+	FrontID int
+	// 会话编号
+	SessionID int
+	// 状态信息
+	StatusMsg string
+	// 是否本次登录后的执行宣告
+	IsLocal bool
+}
+
+// QuoteField 报价(做市商双边报价)
+type QuoteField struct {
+	// 交易帐号
+	InvestorID string
+	// 合约代码
+	InstrumentID string
+	// 报价引用
+	QuoteRef string
+	// 交易所代码
+	ExchangeID string
+	// 报价编号
+	QuoteSysID string
+	// 应价编号(回应询价时)
+	ForQuoteSysID string
+	// 买价格
+	BidPrice float64
+	// 买数量
+	BidVolume int
+	// 买开平标志
+	BidOffsetFlag OffsetFlagType
+	// 卖价格
+	AskPrice float64
+	// 卖数量
+	AskVolume int
+	// 卖开平标志
+	AskOffsetFlag OffsetFlagType
+	// 投机套保标志
+	HedgeFlag HedgeFlagType
+	// 买方向衍生委托 (Orders 的 key)
+	BidOrderID string
+	// 卖方向衍生委托 (Orders 的 key)
+	AskOrderID string
+	// 报价状态
+	QuoteStatus OrderStatusType
+	// 报单日期
+	InsertDate string
+	// 插入时间
+	InsertTime string
+	// 撤销时间
+	CancelTime string
+	// 前置编号
+	FrontID int
+	// 会话编号
+	SessionID int
+	// 状态信息
+	StatusMsg string
+	// 是否本次登录后的报价
+	IsLocal bool
+}
+
+// ForQuoteField 询价通知
+type ForQuoteField struct {
+	// 交易日
+	TradingDay string
+	// 合约代码
+	InstrumentID string
+	// 交易所代码
+	ExchangeID string
+	// 询价编号
+	ForQuoteSysID string
+	// 询价时间
+	ForQuoteTime string
+	// 业务日期
+	ActionDay string
+}
+
+// ParkedOrderField 预埋单/预埋撤单
+type ParkedOrderField struct {
+	// 交易帐号
+	InvestorID string
+	// 合约代码
+	InstrumentID string
+	// 交易所代码
+	ExchangeID string
+	// 预埋编号(预埋撤单为预埋撤单编号)
+	ParkedOrderID string
+	// 报单引用(预埋撤单为被撤委托的报单引用)
+	OrderRef string
+	// 买卖方向
+	Direction DirectionType
+	// 开平标志
+	OffsetFlag OffsetFlagType
+	// 投机套保标志
+	HedgeFlag HedgeFlagType
+	// 价格
+	LimitPrice float64
+	// 数量
+	VolumeTotalOriginal int
+	// 预埋状态
+	Status ParkedOrderStatusType
+	// 是否预埋撤单
+	IsAction bool
+	// 委托 (Orders 的 key): 预埋单触发后生成的委托, 或预埋撤单要撤的委托
+	OrderID string
+	// 错误代码
+	ErrorID int
+	// 错误信息
+	ErrorMsg string
+}
+
+// CombActionField 组合/拆分指令
+type CombActionField struct {
+	// 交易帐号
+	InvestorID string
+	// 组合合约代码, 如 SP a2301&a2305
+	InstrumentID string
+	// 交易所代码
+	ExchangeID string
+	// 组合引用
+	CombActionRef string
+	// 买卖方向(第一腿)
+	Direction DirectionType
+	// 数量
+	Volume int
+	// 组合/拆分
+	CombDirection CombDirectionType
+	// 投机套保标志
+	HedgeFlag HedgeFlagType
+	// 指令状态
+	ActionStatus CombActionStatusType
+	// 组合编号
+	ComTradeID string
+	// 前置编号
+	FrontID int
+	// 会话编号
+	SessionID int
+	// 状态信息
+	StatusMsg string
+	// 是否本次登录后的指令
+	IsLocal bool
+}
+
+// OptionSelfCloseField 期权自对冲
+type OptionSelfCloseField struct {
+	// 交易帐号
+	InvestorID string
+	// 合约代码
+	InstrumentID string
+	// 期权自对冲引用
+	OptionSelfCloseRef string
+	// 交易所代码
+	ExchangeID string
+	// 期权自对冲编号
+	OptionSelfCloseSysID string
+	// 数量
+	Volume int
+	// 投机套保标志
+	HedgeFlag HedgeFlagType
+	// 期权自对冲标识
+	OptSelfCloseFlag OptSelfCloseFlagType
+	// 执行结果
+	ExecResult ExecResultType
+	// 报单日期
+	InsertDate string
+	// 插入时间
+	InsertTime string
+	// 撤销时间
+	CancelTime string
+	// 前置编号
+	FrontID int
+	// 会话编号
+	SessionID int
+	// 状态信息
+	StatusMsg string
+	// 是否本次登录后的申请
+	IsLocal bool
+}
+
+// CondOrderField 本地条件单
+type CondOrderField struct {
+	// 条件单编号
+	CondOrderID string
+	// 委托请求(触发条件为 ContingentCondition/StopPrice)
+	Request OrderRequest
+	// 跟踪止损距离(>0 时为跟踪止损)
+	TrailDistance float64
+	// 状态
+	Status CondOrderStatusType
+	// 委托 (Orders 的 key), 触发后赋值
+	OrderID string
+	// 交易日
+	TradingDay string
+	// 插入时间
+	InsertTime string
+	// 触发时间(行情时间)
+	TriggerTime string
+	// 撤销时间
+	CancelTime string
+}
+
+// OrderStatField 合约当日委托统计
+type OrderStatField struct {
+	// 交易日
+	TradingDay string
+	// 合约代码
+	InstrumentID string
+	// 委托数(已报入交易所)
+	Orders int
+	// 撤单数(已撤销且非错单, 含登录时回放的委托)
+	Cancels int
+	// 错单数(交易所拒绝, 不含 CTP/本地风控拒绝)
+	Rejects int
+	// 自成交数
+	SelfTrades int
+}
+
+// KillSummaryField 全部撤单平仓结果
+type KillSummaryField struct {
+	// 开始时间
+	StartTime string
+	// 结束时间
+	EndTime string
+	// 重试轮数
+	Rounds int
+	// 发出的撤单数
+	Canceled int
+	// 平仓委托
+	Orders []string
+	// 平仓成交数量
+	Closed int
+	// 已全部平仓
+	Flat bool
+	// 未平的持仓
+	Positions []PositionField
+}
+
 // TransferField 银转响应
 type TransferField struct {
 	Time       string  // 时间
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -21,30 +22,70 @@ type HFTrade struct {
 	TradingDay string // 交易日
 	passWord   string
 	SessionID  int // 判断是否自己的委托用
+	frontID    int // 撤销未确认的委托用
 
 	Instruments       sync.Map                 // 合约列表 (key: InstrumentID, value: *InstrumentField)
 	InstrumentStatuss sync.Map                 // 合约状态 (key: InstrumentID, value: *InstrumentStatus)
 	posiDetail        map[string]*sync.Map     // 原始持仓
-	Positions         sync.Map                 // 合成后的持仓 (key: instrument_long/short value: *ctp.CThostFtdcInvestorPositionField)
+	Positions         sync.Map                 // 合成后的持仓 (key: PositionKey 如 instrument_long/short, 非投机追加投保标志 value: *PositionField)
 	Orders            sync.Map                 // 委托 (key: sessionID_OrderRef, value: *OrderField)
+	ExecOrders        sync.Map                 // 执行宣告 (key: sessionID_ExecOrderRef, value: *ExecOrderField)
+	Quotes            sync.Map                 // 报价 (key: sessionID_QuoteRef, value: *QuoteField)
+	ParkedOrders      sync.Map                 // 预埋单/预埋撤单 (key: sessionID_OrderRef|OrderActionRef, 查询到的其他会话的为 ParkedOrderID, value: *ParkedOrderField)
+	OptionSelfCloses  sync.Map                 // 期权自对冲 (key: sessionID_OptionSelfCloseRef, value: *OptionSelfCloseField)
+	CombActions       sync.Map                 // 组合/拆分指令 (key: sessionID_CombActionRef, value: *CombActionField)
+	CondOrders        sync.Map                 // 本地条件单 (key: CondOrderID, value: *CondOrderField)
 	Trades            sync.Map                 // 成交 (key: TradeID_buy/sell, value: &TradeField)
 	sysID4Order       sync.Map                 // key:OrderSysID,value: *OrderField
+	sentOrders        sync.Map                 // 已发出尚未确认的委托 (key: sessionID_OrderRef, value: *OrderField)
+	modifies          sync.Map                 // 改单中的委托 (key: sessionID_OrderRef, value: *orderModify)
+	selfTrades        sync.Map                 // 等待交叉挂单撤销的委托 (key: sessionID_OrderRef, value: *selfTradeWait)
 	Account           *AccountField            // 帐户权益
 	UserAccounts      map[string]*AccountField // 交易员:多帐户权益 string->*AccountField
 	UserPositions     map[string]*sync.Map     // 交易员:多帐户持仓
 	Investors         map[string]struct{}      // 多个帐号(交易员)
+	batchExchanges    map[string]struct{}      // 支持批量撤单的交易所
+	modifyExchanges   map[string]struct{}      // 支持原生改单的交易所
+	lockProducts      map[string]struct{}      // 平今时锁仓的品种/合约
+	riskRules         []RiskRuleType           // 委托前风控规则
+	ticks             sync.Map                 // 合约最新行情 (key: InstrumentID, value: *TickField)
+	cancelLimit       int                      // 合约当日撤单上限
+	killing           int32                    // 全部撤单平仓中(KillSwitch, atomic)
+	waiters           sync.Map                 // 同步请求 (key: chan *RspInfoField, value: RequestID/委托编号)
+	qryInstruments    sync.Map                 // 同步查询合约 (key: RequestID, value: *[]*InstrumentField)
+	logins            sync.Map                 // 同步登录的响应 (key: RequestID, value: *RspUserLoginField)
 
 	IsLogin     bool                     // 登录成功
 	Version     string                   // 版本号,如 v6.5.1_20200908 10:25:08
 	PrivateMode ctp.THOST_TE_RESUME_TYPE // 私有流模式
+	HedgeFlag   HedgeFlagType            // 委托默认投保标志(默认投机)
+	SelfTrade   SelfTradeType            // 自成交处理(默认不检查)
 
 	// qryTicker *time.Ticker   // 循环查询
 	waitLogin sync.WaitGroup // 登录信号
-	waitQry   sync.WaitGroup // 查询指令后等待响应完成
+	loginWait int32          // 登录流程中, 持仓查询完成后 waitLogin.Done (atomic)
+	instID    int            // 登录流程查询合约的 RequestID
+	condMu    sync.Mutex     // 本地条件单触发/撤销
+	condFile  string         // 本地条件单保存文件
+	condID    int64          // 本地条件单编号(condMu)
+	condSave  *time.Timer    // 跟踪止损延迟保存(condMu)
 
-	reqID    int // requestid
-	cntOrder int // 计算order数量
-	cntTrade int // 计算trade数量
+	statMu    sync.Mutex                 // 委托统计
+	stats     map[string]*instrumentStat // 合约当日委托统计 (key: InstrumentID, statMu)
+	canceling map[string]string          // 已发出撤单尚未完成的委托 (key: sessionID_OrderRef, value: InstrumentID, statMu)
+	posiQry   int64                      // 持仓查询发出的序号(atomic)
+	posiDone  int64                      // 已完成的持仓查询序号(atomic, KillSwitch 交易员模式等待持仓刷新)
+
+	qryMu     sync.Mutex     // 查询队列
+	qryItems  []func()       // 待发出的查询(qryMu)
+	qrySignal chan struct{}  // 有新的查询
+	qryDone   chan struct{}  // 已发出的查询响应完成
+	qryStop   chan struct{}  // 队列停止信号, nil 为未启动(qryMu)
+	qryWait   sync.WaitGroup // 队列协程退出
+
+	reqID    int64 // requestid(atomic)
+	cntOrder int   // 计算order数量
+	cntTrade int   // 计算trade数量
 
 	onFrontConnected      OnFrontConnectedType // 事件
 	onFrontDisConnected   OnFrontDisConnectedType
@@ -57,6 +98,23 @@ type HFTrade struct {
 	onRtnInstrumentStatus OnRtnInstrumentStatusType
 	onRtnBankToFuture     OnRtnFromBankToFutureByFuture
 	onRtnFutureToBank     OnRtnFromFutureToBankByFuture
+	onRtnExecOrder        OnRtnExecOrderType
+	onErrRtnExecOrder     OnRtnErrExecOrderType
+	onErrExecAction       OnRtnErrActionType
+	onRtnQuote            OnRtnQuoteType
+	onErrRtnQuote         OnRtnErrQuoteType
+	onErrQuoteAction      OnRtnErrActionType
+	onErrRtnForQuote      OnRtnErrForQuoteType
+	onRtnForQuote         OnRtnForQuoteType
+	onRtnParkedOrder      OnRtnParkedOrderType
+	onErrRtnParkedOrder   OnRtnErrParkedOrderType
+	onRtnCombAction       OnRtnCombActionType
+	onErrRtnCombAction    OnRtnErrCombActionType
+	onRtnSelfClose        OnRtnOptionSelfCloseType
+	onErrRtnSelfClose     OnRtnErrOptionSelfCloseType
+	onErrSelfCloseAction  OnRtnErrActionType
+	onRtnOrderModify      OnRtnOrderModifyType
+	onRtnCondOrder        OnRtnCondOrderType
 
 	// 继承类要实现的函数
 	ReqConnect                  ReqConnectType
@@ -76,6 +134,24 @@ type HFTrade struct {
 	ReqQryInvestor              ReqQryInvestorType
 	ReqQryOrder                 ReqQryOrderType
 	ReqQryTrade                 ReqQryTradeType
+	ReqExecOrder                ReqExecOrderInsertType         // 可选: 期权行权
+	ReqExecAction               ReqExecOrderActionType         // 可选: 期权行权
+	ReqQuote                    ReqQuoteInsertType             // 可选: 做市商报价
+	ReqActionQuote              ReqQuoteActionType             // 可选: 做市商报价
+	ReqForQuote                 ReqForQuoteInsertType          // 可选: 询价
+	ReqParkedOrder              ReqParkedOrderInsertType       // 可选: 预埋单
+	ReqParkedAction             ReqParkedOrderActionType       // 可选: 预埋单
+	ReqRemoveParked             ReqRemoveParkedOrderType       // 可选: 预埋单
+	ReqRemoveParkedAction       ReqRemoveParkedOrderActionType // 可选: 预埋单
+	ReqQryParked                ReqQryParkedOrderType          // 可选: 预埋单
+	ReqQryParkedAction          ReqQryParkedOrderActionType    // 可选: 预埋单
+	ReqCombAction               ReqCombActionInsertType        // 可选: 组合持仓
+	ReqQryComb                  ReqQryCombActionType           // 可选: 组合持仓
+	ReqOptionSelfClose          ReqOptionSelfCloseInsertType   // 可选: 期权自对冲
+	ReqActionOptionSelfClose    ReqOptionSelfCloseActionType   // 可选: 期权自对冲
+	ReqQrySelfClose             ReqQryOptionSelfCloseType      // 可选: 期权自对冲
+	ReqBatchAction              ReqBatchOrderActionType        // 可选: 批量撤单
+	ReqQryDepth                 ReqQryDepthMarketDataType      // 可选: 查询行情(KillSwitch 无行情时)
 }
 type ReqAuthenticateType func(*ctp.CThostFtdcReqAuthenticateField, int)
 type ReqUserLoginType func(*ctp.CThostFtdcReqUserLoginField, int)
@@ -93,6 +169,24 @@ type GetVersionType func() string
 type ReqQryInvestorType = func(*ctp.CThostFtdcQryInvestorField, int)
 type ReqQryOrderType = func(f *ctp.CThostFtdcQryOrderField, i int)
 type ReqQryTradeType = func(f *ctp.CThostFtdcQryTradeField, i int)
+type ReqExecOrderInsertType = func(*ctp.CThostFtdcInputExecOrderField, int)
+type ReqExecOrderActionType = func(*ctp.CThostFtdcInputExecOrderActionField, int)
+type ReqQuoteInsertType = func(*ctp.CThostFtdcInputQuoteField, int)
+type ReqQuoteActionType = func(*ctp.CThostFtdcInputQuoteActionField, int)
+type ReqForQuoteInsertType = func(*ctp.CThostFtdcInputForQuoteField, int)
+type ReqParkedOrderInsertType = func(*ctp.CThostFtdcParkedOrderField, int)
+type ReqParkedOrderActionType = func(*ctp.CThostFtdcParkedOrderActionField, int)
+type ReqRemoveParkedOrderType = func(*ctp.CThostFtdcRemoveParkedOrderField, int)
+type ReqRemoveParkedOrderActionType = func(*ctp.CThostFtdcRemoveParkedOrderActionField, int)
+type ReqQryParkedOrderType = func(*ctp.CThostFtdcQryParkedOrderField, int)
+type ReqQryParkedOrderActionType = func(*ctp.CThostFtdcQryParkedOrderActionField, int)
+type ReqCombActionInsertType = func(*ctp.CThostFtdcInputCombActionField, int)
+type ReqQryCombActionType = func(*ctp.CThostFtdcQryCombActionField, int)
+type ReqOptionSelfCloseInsertType = func(*ctp.CThostFtdcInputOptionSelfCloseField, int)
+type ReqOptionSelfCloseActionType = func(*ctp.CThostFtdcInputOptionSelfCloseActionField, int)
+type ReqQryOptionSelfCloseType = func(*ctp.CThostFtdcQryOptionSelfCloseField, int)
+type ReqBatchOrderActionType = func(*ctp.CThostFtdcInputBatchOrderActionField, int)
+type ReqQryDepthMarketDataType = func(*ctp.CThostFtdcQryDepthMarketDataField, int)
 
 func (t *HFTrade) Init() {
 	t.PrivateMode = ctp.THOST_TERT_RESTART // 默认 restart
@@ -100,6 +194,9 @@ func (t *HFTrade) Init() {
 	t.UserAccounts = make(map[string]*AccountField)
 	t.UserPositions = make(map[string]*sync.Map)
 	t.Investors = make(map[string]struct{})
+	t.batchExchanges = make(map[string]struct{})
+	t.modifyExchanges = make(map[string]struct{})
+	t.lockProducts = make(map[string]struct{})
 
 	for _, r := range []interface{}{t.ReqQryInvestor, t.ReqAuthenticate, t.ReqUserLogin, t.ReqSettlementInfoConfirm, t.ReqQryInstrument, t.ReqQryClassifiedInstrument, t.ReqQryTradingAccount, t.ReqQryInvestorPosition, t.ReqOrder, t.ReqAction, t.GetVersion} {
 		if r == nil {
@@ -127,24 +224,37 @@ func (t *HFTrade) SetQuick(investorAraay ...string) {
 
 func (t *HFTrade) Release() {
 	if t.IsLogin {
-		t.waitLogin.Add(1)
 		t.IsLogin = false
-		t.waitLogin.Wait()
+		t.qryEnd() // 等待已发出的查询响应完成
 		// 前置开,而后台关时, release 报下面的错误, 不处理则会返回 n 个4096后崩溃
 		// CThostFtdcUserApiImplBase::OnSessionDisconnected[0x7f1a3c000b68][1137639425][ 4097]
 		// DesignError:pthread_mutex_unlock in line 116 of file ../../source/event/Mutex.h
 		t.ReleaseAPI() // 未登录会报错
+	} else {
+		t.qryEnd() // 登录过程中
 	}
 	t.FrontDisConnected(0) // 需手动触发
 }
 
 func (t *HFTrade) getReqID() int {
-	t.reqID++
-	return t.reqID
+	return int(atomic.AddInt64(&t.reqID, 1))
+}
+
+// hedgeFlag 委托默认投保标志
+func (t *HFTrade) hedgeFlag() HedgeFlagType {
+	if t.HedgeFlag == 0 {
+		return HedgeFlagSpeculation
+	}
+	return t.HedgeFlag
 }
 
 // ReqLogin 登录
 func (t *HFTrade) ReqLogin(user, pwd, broker, appID, authCode string) {
+	t.reqLogin(user, pwd, broker, appID, authCode, t.getReqID())
+}
+
+// reqLogin 以 id 为 RequestID 认证及登录, 响应以 id 对应
+func (t *HFTrade) reqLogin(user, pwd, broker, appID, authCode string, id int) {
 	t.UserID = user
 	t.passWord = pwd
 	t.BrokerID = broker
@@ -153,169 +263,124 @@ func (t *HFTrade) ReqLogin(user, pwd, broker, appID, authCode string) {
 	copy(f.UserID[:], user)
 	copy(f.AppID[:], appID)
 	copy(f.AuthCode[:], authCode)
-	t.ReqAuthenticate(&f, t.getReqID())
+	t.ReqAuthenticate(&f, id)
 }
 
 //------------------- 函数封装 ----------------------
-// ReqOrderInsert 限价委托
-func (t *HFTrade) ReqOrderInsertByUser(investor, instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) string {
+// ReqOrderInsertRequest 委托, 返回 sessionID_OrderRef. 先经风控(AddRiskRule)及自成交(SelfTrade)检查, 拒绝时以 RegOnErrRtnOrder 响应
+func (t *HFTrade) ReqOrderInsertRequest(req *OrderRequest) string {
+	var info *RspInfoField
+	// 自成交: 需先撤销的挂单
+	var crossed []string
+	if atomic.LoadInt32(&t.killing) == 1 { // 全部平仓中: 拒绝开仓, 平仓不检查
+		if req.OffsetFlag == OffsetFlagOpen {
+			info = &RspInfoField{ErrorID: -1, ErrorMsg: "全部平仓中, 禁止开仓"}
+		}
+	} else if req, crossed, info = t.selfTrade(req); info == nil { // 可能调整价格, 风控检查最终的委托
+		info = t.riskCheck(req)
+	}
+	investor := req.InvestorID
+	if len(investor) == 0 {
+		investor = t.InvestorID
+	}
 	f := ctp.CThostFtdcInputOrderField{}
 	copy(f.BrokerID[:], t.BrokerID)
-	if info, ok := t.Instruments.Load(instrument); ok {
+	if info, ok := t.Instruments.Load(req.InstrumentID); ok {
 		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
 	}
 	copy(f.UserID[:], t.UserID)
 	copy(f.InvestorID[:], investor)
 	copy(f.AccountID[:], investor)
-	f.IsAutoSuspend = ctp.TThostFtdcBoolType(0)
+	copy(f.BusinessUnit[:], req.BusinessUnit)
+	if req.IsAutoSuspend {
+		f.IsAutoSuspend = ctp.TThostFtdcBoolType(1)
+	}
 	f.IsSwapOrder = ctp.TThostFtdcBoolType(0)
 	f.ForceCloseReason = ctp.THOST_FTDC_FCC_NotForceClose
 	// 参数赋值
 	id := t.getReqID()
 	copy(f.OrderRef[:], fmt.Sprintf("%012d", id))
-	copy(f.InstrumentID[:], instrument)
-	f.Direction = ctp.TThostFtdcDirectionType(buySell)
-	f.CombOffsetFlag[0] = byte(openClose)
-	f.CombHedgeFlag[0] = byte(HedgeFlagSpeculation)
+	copy(f.InstrumentID[:], req.InstrumentID)
+	f.Direction = ctp.TThostFtdcDirectionType(req.Direction)
+	f.CombOffsetFlag[0] = byte(req.OffsetFlag)
+	f.CombHedgeFlag[0] = byte(t.hedgeFlag())
+	if req.HedgeFlag != 0 {
+		f.CombHedgeFlag[0] = byte(req.HedgeFlag)
+	}
 	// 不同类型的Order
 	f.OrderPriceType = ctp.THOST_FTDC_OPT_LimitPrice
+	if req.PriceType != 0 {
+		f.OrderPriceType = ctp.TThostFtdcOrderPriceTypeType(req.PriceType)
+	}
 	f.TimeCondition = ctp.THOST_FTDC_TC_GFD
+	if req.TimeCondition != 0 {
+		f.TimeCondition = ctp.TThostFtdcTimeConditionType(req.TimeCondition)
+	}
+	copy(f.GTDDate[:], req.GTDDate)
 	f.VolumeCondition = ctp.THOST_FTDC_VC_AV
+	if req.VolumeCondition != 0 {
+		f.VolumeCondition = ctp.TThostFtdcVolumeConditionType(req.VolumeCondition)
+	}
+	f.MinVolume = ctp.TThostFtdcVolumeType(req.MinVolume)
 	f.ContingentCondition = ctp.THOST_FTDC_CC_Immediately
-	f.LimitPrice = ctp.TThostFtdcPriceType(price)
-	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(volume)
-	t.ReqOrder(&f, id)
+	if req.ContingentCondition != 0 {
+		f.ContingentCondition = ctp.TThostFtdcContingentConditionType(req.ContingentCondition)
+	}
+	f.StopPrice = ctp.TThostFtdcPriceType(req.StopPrice)
+	f.LimitPrice = ctp.TThostFtdcPriceType(req.LimitPrice)
+	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(req.Volume)
+	if info != nil { // 风控/自成交拒绝
+		t.rejectOrder(&f, info)
+	} else if len(crossed) > 0 { // 先撤销交叉的挂单
+		t.selfTradePark(&f, id, crossed)
+	} else {
+		t.orderSent(&f)
+		t.ReqOrder(&f, id)
+	}
 	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:]))
 }
 
+// ReqOrderInsertByUser 限价委托(指定帐号)
+func (t *HFTrade) ReqOrderInsertByUser(investor, instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) string {
+	return t.ReqOrderInsertRequest(NewOrderRequest(instrument, buySell, openClose, price, volume).SetInvestor(investor))
+}
+
 // ReqOrderInsert 限价委托
 func (t *HFTrade) ReqOrderInsert(instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) string {
-	f := ctp.CThostFtdcInputOrderField{}
-	copy(f.BrokerID[:], t.BrokerID)
-	if info, ok := t.Instruments.Load(instrument); ok {
-		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
-	}
-	copy(f.UserID[:], t.UserID)
-	copy(f.InvestorID[:], t.InvestorID)
-	copy(f.AccountID[:], t.InvestorID)
-	f.IsAutoSuspend = ctp.TThostFtdcBoolType(0)
-	f.IsSwapOrder = ctp.TThostFtdcBoolType(0)
-	f.ForceCloseReason = ctp.THOST_FTDC_FCC_NotForceClose
-	// 参数赋值
-	id := t.getReqID()
-	copy(f.OrderRef[:], fmt.Sprintf("%012d", id))
-	copy(f.InstrumentID[:], instrument)
-	f.Direction = ctp.TThostFtdcDirectionType(buySell)
-	f.CombOffsetFlag[0] = byte(openClose)
-	f.CombHedgeFlag[0] = byte(HedgeFlagSpeculation)
-	// 不同类型的Order
-	f.OrderPriceType = ctp.THOST_FTDC_OPT_LimitPrice
-	f.TimeCondition = ctp.THOST_FTDC_TC_GFD
-	f.VolumeCondition = ctp.THOST_FTDC_VC_AV
-	f.ContingentCondition = ctp.THOST_FTDC_CC_Immediately
-	f.LimitPrice = ctp.TThostFtdcPriceType(price)
-	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(volume)
-	t.ReqOrder(&f, id)
-	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:]))
+	return t.ReqOrderInsertRequest(NewOrderRequest(instrument, buySell, openClose, price, volume))
 }
 
 // ReqOrderInsertMarket 市价委托
 func (t *HFTrade) ReqOrderInsertMarket(instrument string, buySell DirectionType, openClose OffsetFlagType, volume int) string {
-	f := ctp.CThostFtdcInputOrderField{}
-	copy(f.BrokerID[:], t.BrokerID)
-	if info, ok := t.Instruments.Load(instrument); ok {
-		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
-	}
-	copy(f.UserID[:], t.UserID)
-	copy(f.InvestorID[:], t.InvestorID)
-	copy(f.AccountID[:], t.InvestorID)
-	f.IsAutoSuspend = ctp.TThostFtdcBoolType(0)
-	f.IsSwapOrder = ctp.TThostFtdcBoolType(0)
-	f.ForceCloseReason = ctp.THOST_FTDC_FCC_NotForceClose
-	// 参数赋值
-	id := t.getReqID()
-	copy(f.OrderRef[:], fmt.Sprintf("%012d", id))
-	copy(f.InstrumentID[:], instrument)
-	f.Direction = ctp.TThostFtdcDirectionType(buySell)
-	f.CombOffsetFlag[0] = byte(openClose)
-	f.CombHedgeFlag[0] = byte(HedgeFlagSpeculation)
-	// 不同类型的Order
-	f.OrderPriceType = ctp.THOST_FTDC_OPT_AnyPrice
-	f.TimeCondition = ctp.THOST_FTDC_TC_IOC
-	f.VolumeCondition = ctp.THOST_FTDC_VC_AV
-	f.ContingentCondition = ctp.THOST_FTDC_CC_Immediately
-	f.LimitPrice = ctp.TThostFtdcPriceType(0)
-	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(volume)
-	t.ReqOrder(&f, id)
-	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:]))
+	return t.ReqOrderInsertRequest(NewOrderRequest(instrument, buySell, openClose, 0, volume).SetMarket())
 }
 
 // ReqOrderInsertFOK FOK委托[部成撤单]
 func (t *HFTrade) ReqOrderInsertFOK(instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) string {
-	f := ctp.CThostFtdcInputOrderField{}
-	copy(f.BrokerID[:], t.BrokerID)
-	if info, ok := t.Instruments.Load(instrument); ok {
-		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
-	}
-	copy(f.UserID[:], t.UserID)
-	copy(f.InvestorID[:], t.InvestorID)
-	copy(f.AccountID[:], t.InvestorID)
-	f.IsAutoSuspend = ctp.TThostFtdcBoolType(0)
-	f.IsSwapOrder = ctp.TThostFtdcBoolType(0)
-	f.ForceCloseReason = ctp.THOST_FTDC_FCC_NotForceClose
-	// 参数赋值
-	id := t.getReqID()
-	copy(f.OrderRef[:], fmt.Sprintf("%012d", id))
-	copy(f.InstrumentID[:], instrument)
-	f.Direction = ctp.TThostFtdcDirectionType(buySell)
-	f.CombOffsetFlag[0] = byte(openClose)
-	f.CombHedgeFlag[0] = byte(HedgeFlagSpeculation)
-	// 不同类型的Order
-	f.OrderPriceType = ctp.THOST_FTDC_OPT_LimitPrice
-	f.TimeCondition = ctp.THOST_FTDC_TC_GFD
-	f.VolumeCondition = ctp.THOST_FTDC_VC_CV
-	f.ContingentCondition = ctp.THOST_FTDC_CC_Immediately
-	f.LimitPrice = ctp.TThostFtdcPriceType(price)
-	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(volume)
-	t.ReqOrder(&f, id)
-	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:]))
+	return t.ReqOrderInsertRequest(NewOrderRequest(instrument, buySell, openClose, price, volume).SetVolumeCondition(VolumeConditionCV))
 }
 
 // ReqOrderInsertFAK FAK委托[全成or撤单]
 func (t *HFTrade) ReqOrderInsertFAK(instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) string {
-	f := ctp.CThostFtdcInputOrderField{}
-	copy(f.BrokerID[:], t.BrokerID)
-	if info, ok := t.Instruments.Load(instrument); ok {
-		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
-	}
-	copy(f.UserID[:], t.UserID)
-	copy(f.InvestorID[:], t.InvestorID)
-	copy(f.AccountID[:], t.InvestorID)
-	f.IsAutoSuspend = ctp.TThostFtdcBoolType(0)
-	f.IsSwapOrder = ctp.TThostFtdcBoolType(0)
-	f.ForceCloseReason = ctp.THOST_FTDC_FCC_NotForceClose
-	// 参数赋值
-	id := t.getReqID()
-	copy(f.OrderRef[:], fmt.Sprintf("%012d", id))
-	copy(f.InstrumentID[:], instrument)
-	f.Direction = ctp.TThostFtdcDirectionType(buySell)
-	f.CombOffsetFlag[0] = byte(openClose)
-	f.CombHedgeFlag[0] = byte(HedgeFlagSpeculation)
-	// 不同类型的Order
-	f.OrderPriceType = ctp.THOST_FTDC_OPT_LimitPrice
-	f.TimeCondition = ctp.THOST_FTDC_TC_IOC
-	f.VolumeCondition = ctp.THOST_FTDC_VC_AV
-	f.ContingentCondition = ctp.THOST_FTDC_CC_Immediately
-	f.LimitPrice = ctp.TThostFtdcPriceType(price)
-	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(volume)
-	t.ReqOrder(&f, id)
-	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:]))
+	return t.ReqOrderInsertRequest(NewOrderRequest(instrument, buySell, openClose, price, volume).SetTimeCondition(TimeConditionIOC))
 }
 
-// ReqOrderAction 撤单
+// ReqOrderAction 撤单. 返回 0: 已发出 -1: 委托不存在 -2: 合约撤单数达到上限(SetCancelLimit)
 func (t *HFTrade) ReqOrderAction(orderID string) int {
-	if o, ok := t.Orders.Load(orderID); ok {
+	return t.reqOrderAction(orderID, t.getReqID())
+}
+
+// reqOrderAction 以 actionRef 为 OrderActionRef 撤单
+func (t *HFTrade) reqOrderAction(orderID string, actionRef int) int {
+	o, ok := t.Orders.Load(orderID)
+	if !ok { // 尚未确认的委托
+		o, ok = t.sentOrders.Load(orderID)
+	}
+	if ok {
 		var order = o.(*OrderField)
+		if !t.statActions(map[string]string{orderID: order.InstrumentID}) {
+			return -2
+		}
 		f := ctp.CThostFtdcInputOrderActionField{}
 		copy(f.BrokerID[:], t.BrokerID)
 		copy(f.UserID[:], t.UserID)
@@ -326,7 +391,8 @@ func (t *HFTrade) ReqOrderAction(orderID string) int {
 		f.ActionFlag = ctp.THOST_FTDC_AF_Delete
 		f.FrontID = ctp.TThostFtdcFrontIDType(order.FrontID)
 		f.SessionID = ctp.TThostFtdcSessionIDType(order.SessionID)
-		t.ReqAction(&f, t.getReqID())
+		f.OrderActionRef = ctp.TThostFtdcOrderActionRefType(actionRef)
+		t.ReqAction(&f, actionRef)
 		return 0
 	}
 	return -1
@@ -515,7 +581,7 @@ func (t *HFTrade) RtnTrade(field *ctp.CThostFtdcTradeField) {
 	} else {
 		key = "error"
 	}
-	tf, _ := t.Trades.LoadOrStore(key, &TradeField{
+	tf, loaded := t.Trades.LoadOrStore(key, &TradeField{
 		InvestorID:   Bytes2String(field.InvestorID[:]),
 		Direction:    DirectionType(field.Direction),
 		HedgeFlag:    HedgeFlagType(field.HedgeFlag),
@@ -531,17 +597,22 @@ func (t *HFTrade) RtnTrade(field *ctp.CThostFtdcTradeField) {
 		TradeID:      tradeid,
 	})
 	var f = tf.(*TradeField)
+	if !loaded && field.Direction == ctp.THOST_FTDC_D_Buy { // 同一成交编号 买/卖 均为本帐户: 自成交
+		if sell, ok := t.Trades.Load(tradeid + "_sell"); ok && sell.(*TradeField).InstrumentID == f.InstrumentID {
+			t.statSelfTrade(f.InstrumentID)
+		}
+	} else if !loaded && field.Direction == ctp.THOST_FTDC_D_Sell {
+		if buy, ok := t.Trades.Load(tradeid + "_buy"); ok && buy.(*TradeField).InstrumentID == f.InstrumentID {
+			t.statSelfTrade(f.InstrumentID)
+		}
+	}
 	if t.IsLogin && len(t.Investors) == 1 { // 登录后：更新持仓 // 交易员不处理
 		if f.OffsetFlag == OffsetFlagOpen { // 开仓
-			var key string
 			var posiDire = PosiDirectionLong
-			if f.Direction == DirectionBuy {
-				key = fmt.Sprintf("%s_long", f.InstrumentID)
-			} else {
-				key = fmt.Sprintf("%s_short", f.InstrumentID)
+			if f.Direction == DirectionSell {
 				posiDire = PosiDirectionShort
 			}
-			pf, _ := t.Positions.LoadOrStore(key, &PositionField{
+			pf, _ := t.Positions.LoadOrStore(PositionKey(f.InstrumentID, posiDire, f.HedgeFlag), &PositionField{
 				InvestorID:        f.InvestorID,
 				InstrumentID:      f.InstrumentID,
 				PositionDirection: posiDire,
@@ -557,13 +628,11 @@ func (t *HFTrade) RtnTrade(field *ctp.CThostFtdcTradeField) {
 			p.Position += f.Volume
 			p.TodayPosition += f.Volume
 		} else {
-			var key string
+			var posiDire = PosiDirectionLong
 			if f.Direction == DirectionBuy {
-				key = fmt.Sprintf("%s_short", f.InstrumentID)
-			} else {
-				key = fmt.Sprintf("%s_long", f.InstrumentID)
+				posiDire = PosiDirectionShort
 			}
-			if posi, ok := t.Positions.Load(key); ok {
+			if posi, ok := t.Positions.Load(PositionKey(f.InstrumentID, posiDire, f.HedgeFlag)); ok {
 				var p = posi.(*PositionField)
 				p.OpenVolume -= f.Volume
 				p.OpenAmount -= f.Price * float64(f.Volume)
@@ -616,6 +685,12 @@ func (t *HFTrade) RtnTrade(field *ctp.CThostFtdcTradeField) {
 		if t.IsLogin && t.onRtnOrder != nil {
 			t.onRtnOrder(o)
 		}
+		if o.OrderStatus == OrderStatusAllTraded {
+			t.statActionDone(fmt.Sprintf("%d_%s", o.SessionID, o.OrderRef))
+		}
+		if t.IsLogin && o.OrderStatus == OrderStatusAllTraded {
+			t.selfTradeDone(fmt.Sprintf("%d_%s", o.SessionID, o.OrderRef), nil)
+		}
 	}
 	// 客户端响应
 	if t.IsLogin && t.onRtnTrade != nil {
@@ -632,6 +707,8 @@ func (t *HFTrade) RtnOrder(field *ctp.CThostFtdcOrderField) {
 	}
 	t.cntOrder++
 	key := fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.OrderRef[:]))
+	defer t.notify(key, nil)       // 同步委托/撤单 InsertOrder/CancelOrder
+	defer t.sentOrders.Delete(key) // 已确认
 	if of, exists := t.Orders.LoadOrStore(key, &OrderField{
 		InvestorID:          Bytes2String(field.InvestorID[:]),
 		InstrumentID:        Bytes2String(field.InstrumentID[:]),
@@ -641,27 +718,34 @@ func (t *HFTrade) RtnOrder(field *ctp.CThostFtdcOrderField) {
 		Direction:           DirectionType(field.Direction),
 		OffsetFlag:          OffsetFlagType(field.CombOffsetFlag[0]),
 		HedgeFlag:           HedgeFlagType(field.CombHedgeFlag[0]),
+		PriceType:           OrderPriceTypeType(field.OrderPriceType),
 		LimitPrice:          float64(field.LimitPrice),
+		TimeCondition:       TimeConditionType(field.TimeCondition),
+		VolumeCondition:     VolumeConditionType(field.VolumeCondition),
+		MinVolume:           int(field.MinVolume),
 		VolumeTotalOriginal: int(field.VolumeTotalOriginal),
 		VolumeLeft:          int(field.VolumeTotalOriginal),
 		ExchangeID:          Bytes2String(field.ExchangeID[:]),
+		OrderSubmitStatus:   OrderSubmitStatusType(field.OrderSubmitStatus),
 		InsertDate:          Bytes2String(field.InsertDate[:]),
 		InsertTime:          Bytes2String(field.InsertTime[:]),
 		OrderStatus:         OrderStatusNoTradeQueueing, // OrderStatusType(orderField.OrderStatus)
 		StatusMsg:           "委托已提交",                    // bytes2GBKbytes2GBKString(orderField.StatusMsg[:])
 		IsLocal:             int(field.SessionID) == t.SessionID,
 	}); !exists { // 新添加
+		t.statOrder(Bytes2String(field.InstrumentID[:]))
+		t.parkedSend(key, of.(*OrderField))
 		if t.IsLogin && t.onRtnOrder != nil {
 			// 平仓指令, 冻结持仓(随后的持仓查询会进行修正),冻结持仓恢复会滞后 <=2s
 			f := of.(*OrderField)
 			if f.OffsetFlag != OffsetFlagOpen {
 				if f.Direction == DirectionBuy { // 冻结空头
-					key := fmt.Sprintf("%s_short", f.InstrumentID)
+					key := PositionKey(f.InstrumentID, PosiDirectionShort, f.HedgeFlag)
 					if posiField, ok := t.Positions.Load(key); ok {
 						posiField.(*PositionField).LongFrozen += f.VolumeTotalOriginal
 					}
 				} else {
-					key := fmt.Sprintf("%s_long", f.InstrumentID)
+					key := PositionKey(f.InstrumentID, PosiDirectionLong, f.HedgeFlag)
 					if posiField, ok := t.Positions.Load(key); ok { // 冻结多头
 						posiField.(*PositionField).ShortFrozen += f.VolumeTotalOriginal
 					}
@@ -671,27 +755,36 @@ func (t *HFTrade) RtnOrder(field *ctp.CThostFtdcOrderField) {
 		}
 	} else {
 		var f = of.(*OrderField)
+		f.OrderSubmitStatus = OrderSubmitStatusType(field.OrderSubmitStatus)
 		if OrderStatusType(field.OrderStatus) == OrderStatusCanceled { // 处理撤单
+			canceled := f.OrderStatus != OrderStatusCanceled
 			f.OrderStatus = OrderStatusCanceled
 			f.StatusMsg = Bytes2String(field.StatusMsg[:])
 			f.CancelTime = Bytes2String(field.CancelTime[:])
+			if canceled {
+				t.statCanceled(key, f.InstrumentID, orderRejected(f))
+			}
 			// 错单
 			if t.IsLogin { // 登录前不响应
 				// 解锁冻结,
 				if f.OffsetFlag != OffsetFlagOpen {
 					if f.Direction == DirectionBuy { // 冻结空头
-						key := fmt.Sprintf("%s_short", f.InstrumentID)
+						key := PositionKey(f.InstrumentID, PosiDirectionShort, f.HedgeFlag)
 						if posiField, ok := t.Positions.Load(key); ok {
 							posiField.(*PositionField).LongFrozen -= f.VolumeLeft
 						}
 					} else {
-						key := fmt.Sprintf("%s_long", f.InstrumentID)
+						key := PositionKey(f.InstrumentID, PosiDirectionLong, f.HedgeFlag)
 						if posiField, ok := t.Positions.Load(key); ok { // 冻结多头
 							posiField.(*PositionField).ShortFrozen -= f.VolumeLeft
 						}
 					}
 				}
-				if strings.Contains(f.StatusMsg, "被拒绝") {
+				t.selfTradeDone(key, nil)     // 自成交: 交叉挂单已撤销
+				if t.modifyCanceled(key, f) { // 改单: 撤单后已重新委托
+					return
+				}
+				if orderRejected(f) {
 					if t.onErrRtnOrder != nil {
 						t.onErrRtnOrder(f, &RspInfoField{
 							ErrorID:  -1,
@@ -707,17 +800,31 @@ func (t *HFTrade) RtnOrder(field *ctp.CThostFtdcOrderField) {
 			if len(f.OrderSysID) > 0 {
 				t.sysID4Order.Store(f.OrderSysID, f)
 			}
+			if t.IsLogin {
+				t.modifyUpdated(key, f, field)
+			}
 		}
 	}
 }
 
 // ErrRtnOrderAction 撤单错误
 func (t *HFTrade) ErrRtnOrderAction(field *ctp.CThostFtdcOrderActionField, info *ctp.CThostFtdcRspInfoField) {
-	if t.IsLogin && t.onErrAction != nil {
-		t.onErrAction(fmt.Sprintf("%d_%s", field.SessionID, field.OrderRef), &RspInfoField{
-			ErrorID:  int(info.ErrorID),
-			ErrorMsg: Bytes2String(info.ErrorMsg[:]),
-		})
+	if !t.IsLogin {
+		return
+	}
+	key := fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.OrderRef[:]))
+	rsp := &RspInfoField{
+		ErrorID:  int(info.ErrorID),
+		ErrorMsg: Bytes2String(info.ErrorMsg[:]),
+	}
+	t.notify(key, rsp)
+	t.statActionDone(key)
+	t.selfTradeDone(key, rsp)
+	if t.modifyFailed(key, int(field.OrderActionRef), rsp) { // 改单失败由改单响应处理
+		return
+	}
+	if t.onErrAction != nil {
+		t.onErrAction(key, rsp)
 	}
 }
 
@@ -726,27 +833,43 @@ func (t *HFTrade) ErrRtnOrderInsert(field *ctp.CThostFtdcInputOrderField, info *
 	if !t.IsLogin { // 过滤当日以前登录时的错误委托
 		return
 	}
-	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.OrderRef[:]))
-	of, _ := t.Orders.LoadOrStore(key, &OrderField{
-		InvestorID:          Bytes2String(field.InvestorID[:]),
-		InstrumentID:        Bytes2String(field.InstrumentID[:]),
-		SessionID:           t.SessionID,
-		FrontID:             0,
-		OrderRef:            Bytes2String(field.OrderRef[:]),
-		Direction:           DirectionType(field.Direction),
-		OffsetFlag:          OffsetFlagType(field.CombOffsetFlag[0]),
-		HedgeFlag:           HedgeFlagType(field.CombHedgeFlag[0]),
-		LimitPrice:          float64(field.LimitPrice),
-		VolumeTotalOriginal: int(field.VolumeTotalOriginal),
-		VolumeLeft:          int(field.VolumeTotalOriginal),
-		ExchangeID:          Bytes2String(field.ExchangeID[:]),
-		IsLocal:             true,
-	})
-	var o = of.(*OrderField)
-	o.OrderStatus = OrderStatusCanceled
-	if t.onErrRtnOrder != nil {
-		t.onErrRtnOrder(o, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+	t.rejectOrder(field, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+}
+
+// RspOrderInsert 委托被前置拒绝(同 ErrRtnOrderInsert, 只响应一次)
+func (t *HFTrade) RspOrderInsert(field *ctp.CThostFtdcInputOrderField, info *ctp.CThostFtdcRspInfoField) {
+	if !t.IsLogin || info.ErrorID == 0 {
+		return
 	}
+	t.rejectOrder(field, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+}
+
+// PositionKey 持仓 key: 投机为 instrument_long/short/net, 其他投保标志追加标志名称, 如 rb2310_long_hedge
+func PositionKey(instrument string, direction PosiDirectionType, hedge HedgeFlagType) string {
+	var key string
+	switch direction {
+	case PosiDirectionLong:
+		key = fmt.Sprintf("%s_long", instrument)
+	case PosiDirectionShort:
+		key = fmt.Sprintf("%s_short", instrument)
+	default:
+		key = fmt.Sprintf("%s_net", instrument)
+	}
+	switch hedge {
+	case HedgeFlagSpeculation, 0:
+		return key
+	case HedgeFlagArbitrage:
+		return key + "_arbitrage"
+	case HedgeFlagHedge:
+		return key + "_hedge"
+	case HedgeFlagMarketMaker:
+		return key + "_marketmaker"
+	case HedgeFlagSpecHedge:
+		return key + "_spechedge"
+	case HedgeFlagHedgeSpec:
+		return key + "_hedgespec"
+	}
+	return fmt.Sprintf("%s_%c", key, hedge)
 }
 
 func (t *HFTrade) positionCom() {
@@ -806,8 +929,17 @@ func (t *HFTrade) positionCom() {
 			mpPosition.Store(key, &pFinal)
 			return true
 		})
-		if investor == t.InvestorID {
-			t.Positions = *mpPosition
+		if investor == t.InvestorID { // sync.Map 不可复制, 逐项替换
+			t.Positions.Range(func(key, _ interface{}) bool {
+				if _, ok := mpPosition.Load(key); !ok {
+					t.Positions.Delete(key)
+				}
+				return true
+			})
+			mpPosition.Range(func(key, value interface{}) bool {
+				t.Positions.Store(key, value)
+				return true
+			})
 		}
 		t.posiDetail[investor] = &sync.Map{} // 数据清空
 	}
@@ -830,15 +962,7 @@ func (t *HFTrade) RspQryInvestorPosition(field *ctp.CThostFtdcInvestorPositionFi
 	instrumentID := Bytes2String(p.InstrumentID[:])
 	if len(instrumentID) > 0 { // 偶尔出现NULL的数据导致数据转换错误
 		if _, ok := t.Instruments.Load(instrumentID); ok { // 解决交易所自主合成某些不可交易的套利合约的问题如 SPC y2005&p2001
-			var key string
-			if p.PosiDirection == ctp.THOST_FTDC_PD_Long {
-				key = fmt.Sprintf("%s_long", Bytes2String(p.InstrumentID[:]))
-			} else if p.PosiDirection == ctp.THOST_FTDC_PD_Short {
-				key = fmt.Sprintf("%s_short", Bytes2String(p.InstrumentID[:]))
-			} else {
-				key = fmt.Sprintf("%s_net", Bytes2String(p.InstrumentID[:]))
-			}
-			// key := fmt.Sprintf("%s_%c", Bytes2String(p.InstrumentID[:]), PosiDirectionType(p.PosiDirection))
+			key := PositionKey(instrumentID, PosiDirectionType(p.PosiDirection), HedgeFlagType(p.HedgeFlag))
 			ps, _ := detail.LoadOrStore(key, make([]*ctp.CThostFtdcInvestorPositionField, 0))
 			ps = append(ps.([]*ctp.CThostFtdcInvestorPositionField), p)
 			detail.Store(key, ps) // append后指针有变化,需重新赋值
@@ -846,18 +970,12 @@ func (t *HFTrade) RspQryInvestorPosition(field *ctp.CThostFtdcInvestorPositionFi
 	}
 	if b {
 		t.positionCom()
-		if !t.IsLogin {
-			t.waitLogin.Done()                 // 通知:1. 登录响应可以发了 2. release 可以继续了
-			time.Sleep(100 * time.Millisecond) //登录过程中是否要等待 islogin 的赋值
+		atomic.StoreInt64(&t.posiDone, atomic.LoadInt64(&t.posiQry))
+		if atomic.CompareAndSwapInt32(&t.loginWait, 1, 0) {
+			t.waitLogin.Done() // 通知: 登录响应可以发了
 		}
-		go func() {
-			if t.IsLogin { // release 后不处理
-				time.Sleep(1100 * time.Millisecond)
-				faccount := ctp.CThostFtdcQryTradingAccountField{}
-				copy(faccount.BrokerID[:], t.BrokerID)
-				t.ReqQryTradingAccount(&faccount, t.getReqID())
-			}
-		}()
+		t.qryFinished()
+		t.qryAccount() // 循环查询, Release 后队列停止
 	}
 }
 
@@ -909,12 +1027,8 @@ func (t *HFTrade) RspQryTradingAccount(field *ctp.CThostFtdcTradingAccountField,
 	acc.MortgageableFund = float64(field.MortgageableFund)
 
 	if b { // 查询完成
-		go func() { // 查持仓
-			time.Sleep(1100 * time.Millisecond)
-			fposition := ctp.CThostFtdcQryInvestorPositionField{}
-			copy(fposition.BrokerID[:], t.BrokerID)
-			t.ReqQryInvestorPosition(&fposition, t.getReqID())
-		}()
+		t.qryFinished()
+		t.qryPosition()
 	}
 }
 
@@ -933,10 +1047,8 @@ func (t *HFTrade) qryUser() {
 	}
 	fmt.Println("orders: ", ordCnt, " trades: ", trdCnt)
 
-	// 改为响应中相互调用,以避免release时,查询处理未完成造成的异常
-	faccount := ctp.CThostFtdcQryTradingAccountField{}
-	copy(faccount.BrokerID[:], t.BrokerID)
-	t.ReqQryTradingAccount(&faccount, t.getReqID())
+	// 改为响应中相互调用(经查询队列),以避免release时,查询处理未完成造成的异常
+	t.qryAccount()
 }
 
 // RspQryOrder 查委托响应
@@ -946,7 +1058,13 @@ func (t *HFTrade) RspQryOrder(field *ctp.CThostFtdcOrderField, b bool) {
 		t.RtnOrder(field)
 	}
 	if b {
-		t.waitQry.Done()
+		t.qryFinished()
+		fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry trade")
+		t.qry(func() {
+			qryTrade := ctp.CThostFtdcQryTradeField{}
+			copy(qryTrade.BrokerID[:], t.BrokerID)
+			t.ReqQryTrade(&qryTrade, t.getReqID())
+		})
 	}
 }
 
@@ -956,7 +1074,9 @@ func (t *HFTrade) RspQryTrade(field *ctp.CThostFtdcTradeField, b bool) {
 		t.RtnTrade(field) // 处理两次,以触发自定义处理的代码
 	}
 	if b {
-		t.waitQry.Done()
+		t.qryFinished()
+		fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry finished.")
+		go t.qryUser() // 回报回放完成后循环查持仓/权益
 	}
 }
 
@@ -965,34 +1085,20 @@ func (t *HFTrade) RspQryInvestor(field *ctp.CThostFtdcInvestorField, b bool) {
 	investorID := Bytes2String(field.InvestorID[:])
 	t.Investors[investorID] = struct{}{}
 	if b {
-		go func() {
-			// qry order
-			time.Sleep(1200 * time.Millisecond)
-			t.waitQry.Add(1)
-			fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry order")
+		t.qryFinished()
+		fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry order")
+		t.qry(func() {
 			qryOrder := ctp.CThostFtdcQryOrderField{}
 			copy(qryOrder.BrokerID[:], t.BrokerID)
 			t.ReqQryOrder(&qryOrder, t.getReqID())
-			t.waitQry.Wait()
-
-			time.Sleep(1200 * time.Millisecond)
-			t.waitQry.Add(1)
-			fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry trade")
-			qryTrade := ctp.CThostFtdcQryTradeField{}
-			copy(qryTrade.BrokerID[:], t.BrokerID)
-			t.ReqQryTrade(&qryTrade, t.getReqID())
-			t.waitQry.Wait()
-			fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry finished.")
-			// 循环查持仓/权益
-			t.qryUser()
-		}()
+		})
 	}
 }
 
 // RspQryInstrument 合约
-func (t *HFTrade) RspQryInstrument(field *ctp.CThostFtdcInstrumentField, b bool) {
+func (t *HFTrade) RspQryInstrument(field *ctp.CThostFtdcInstrumentField, info *ctp.CThostFtdcRspInfoField, reqID int, b bool) {
 	if field != nil {
-		t.Instruments.Store(Bytes2String(field.InstrumentID[:]), &InstrumentField{
+		inst := &InstrumentField{
 			InstrumentID:              Bytes2String(field.InstrumentID[:]),
 			ExchangeID:                Bytes2String(field.ExchangeID[:]),
 			ProductID:                 Bytes2String(field.ProductID[:]),
@@ -1013,16 +1119,27 @@ func (t *HFTrade) RspQryInstrument(field *ctp.CThostFtdcInstrumentField, b bool)
 			ExpireDate:                Bytes2String(field.ExpireDate[:]),
 			StartDelivDate:            Bytes2String(field.StartDelivDate[:]),
 			EndDelivDate:              Bytes2String(field.EndDelivDate[:]),
-		})
+		}
+		t.Instruments.Store(inst.InstrumentID, inst)
+		if qry, ok := t.qryInstruments.Load(reqID); ok { // 同步查询 QryInstrument
+			*qry.(*[]*InstrumentField) = append(*qry.(*[]*InstrumentField), inst)
+		}
+	}
+	if b {
+		t.qryFinished()
+		if info != nil && info.ErrorID != 0 {
+			t.notify(reqID, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+		} else {
+			t.notify(reqID, &RspInfoField{ErrorID: 0, ErrorMsg: "成功"})
+		}
 	}
-	if b && !t.IsLogin {
+	if b && !t.IsLogin && reqID == t.instID { // 登录流程(非同步查询 QryInstrument)
 		if t.PrivateMode == ctp.THOST_TERT_QUICK { // 交易员模式
-			f := ctp.CThostFtdcQryInvestorField{}
-			copy(f.BrokerID[:], t.BrokerID)
-			go func() {
-				time.Sleep(1100 * time.Millisecond)
+			t.qry(func() {
+				f := ctp.CThostFtdcQryInvestorField{}
+				copy(f.BrokerID[:], t.BrokerID)
 				t.ReqQryInvestor(&f, t.getReqID())
-			}()
+			})
 		} else {
 			go t.qryUser()
 		}
@@ -1031,22 +1148,33 @@ func (t *HFTrade) RspQryInstrument(field *ctp.CThostFtdcInstrumentField, b bool)
 
 // RspSettlementInfoConfirm 确认结算
 func (t *HFTrade) RspSettlementInfoConfirm() {
+	id := t.getReqID()
+	t.instID = id
+	t.qry(func() { t.reqQryInstrument(id) })
+}
+
+// reqQryInstrument 查询合约(v6.5.1 起以 ReqQryClassifiedInstrument 查询)
+func (t *HFTrade) reqQryInstrument(id int) {
 	if strings.Compare(t.Version, "v6.5.1") < 0 {
-		t.ReqQryInstrument(&ctp.CThostFtdcQryInstrumentField{}, t.getReqID())
+		t.ReqQryInstrument(&ctp.CThostFtdcQryInstrumentField{}, id)
 	} else {
 		f := ctp.CThostFtdcQryClassifiedInstrumentField{
 			TradingType: ctp.THOST_FTDC_TD_TRADE,
 			ClassType:   ctp.THOST_FTDC_INS_ALL,
 		}
-		t.ReqQryClassifiedInstrument(&f, t.getReqID())
+		t.ReqQryClassifiedInstrument(&f, id)
 	}
 }
 
 // RspUserLogin 登录
-func (t *HFTrade) RspUserLogin(loginField *ctp.CThostFtdcRspUserLoginField, infoField *ctp.CThostFtdcRspInfoField) {
+func (t *HFTrade) RspUserLogin(loginField *ctp.CThostFtdcRspUserLoginField, infoField *ctp.CThostFtdcRspInfoField, reqID int) {
 	if infoField.ErrorID == 0 {
 		t.SessionID = int(loginField.SessionID)
-		t.TradingDay = Bytes2String(loginField.TradingDay[:])
+		t.frontID = int(loginField.FrontID)
+		if day := Bytes2String(loginField.TradingDay[:]); day != t.TradingDay { // 交易日切换: 统计重新累计
+			t.statReset()
+			t.TradingDay = day
+		}
 		// investor 赋值
 		t.InvestorID = t.UserID
 		if t.PrivateMode == ctp.THOST_TERT_RESTART {
@@ -1054,48 +1182,48 @@ func (t *HFTrade) RspUserLogin(loginField *ctp.CThostFtdcRspUserLoginField, info
 		}
 
 		// 用waitgroup控制登录消息发送信号
-		if t.onRspUserLogin != nil {
-			t.waitLogin.Add(1)
-			go func(field *RspUserLoginField) {
-				f := ctp.CThostFtdcSettlementInfoConfirmField{}
-				copy(f.InvestorID[:], t.UserID)
-				// copy(f.AccountID[:], t.InvestorID)
-				copy(f.BrokerID[:], t.BrokerID)
-				t.ReqSettlementInfoConfirm(&f, t.getReqID())
-
-				t.waitLogin.Wait()
-				// 登录成功响应
-				t.IsLogin = true
-				t.onRspUserLogin(field, &RspInfoField{ErrorID: 0, ErrorMsg: "成功"})
-			}(&RspUserLoginField{
-				TradingDay:  t.TradingDay,
-				LoginTime:   Bytes2String(loginField.LoginTime[:]),
-				BrokerID:    t.BrokerID,
-				UserID:      t.UserID,
-				FrontID:     int(loginField.FrontID),
-				SessionID:   t.SessionID,
-				MaxOrderRef: Bytes2String(loginField.MaxOrderRef[:]),
-				SystemName:  Bytes2String(loginField.SystemName[:]),
-				SysVersion:  Bytes2String(loginField.SysVersion[:]),
-			})
-		}
+		t.waitLogin.Add(1)
+		atomic.StoreInt32(&t.loginWait, 1)
+		t.qryStart()
+		go func(field *RspUserLoginField) {
+			f := ctp.CThostFtdcSettlementInfoConfirmField{}
+			copy(f.InvestorID[:], t.UserID)
+			// copy(f.AccountID[:], t.InvestorID)
+			copy(f.BrokerID[:], t.BrokerID)
+			t.ReqSettlementInfoConfirm(&f, t.getReqID())
+
+			t.waitLogin.Wait()
+			// 登录成功响应
+			t.IsLogin = true
+			t.rspUserLogin(reqID, field, &RspInfoField{ErrorID: 0, ErrorMsg: "成功"})
+		}(&RspUserLoginField{
+			TradingDay:  t.TradingDay,
+			LoginTime:   Bytes2String(loginField.LoginTime[:]),
+			BrokerID:    t.BrokerID,
+			UserID:      t.UserID,
+			FrontID:     int(loginField.FrontID),
+			SessionID:   t.SessionID,
+			MaxOrderRef: Bytes2String(loginField.MaxOrderRef[:]),
+			SystemName:  Bytes2String(loginField.SystemName[:]),
+			SysVersion:  Bytes2String(loginField.SysVersion[:]),
+		})
 	} else {
-		t.onRspUserLogin(&RspUserLoginField{}, &RspInfoField{ErrorID: int(infoField.ErrorID), ErrorMsg: Bytes2String(infoField.ErrorMsg[:])})
+		t.rspUserLogin(reqID, &RspUserLoginField{}, &RspInfoField{ErrorID: int(infoField.ErrorID), ErrorMsg: Bytes2String(infoField.ErrorMsg[:])})
 	}
 }
 
-// RspAuthenticate 认证
-func (t *HFTrade) RspAuthenticate(info *ctp.CThostFtdcRspInfoField) {
+// RspAuthenticate 认证, 以同一 RequestID 登录
+func (t *HFTrade) RspAuthenticate(info *ctp.CThostFtdcRspInfoField, reqID int) {
 	if info.ErrorID == 0 {
 		f := ctp.CThostFtdcReqUserLoginField{}
 		copy(f.UserID[:], t.UserID)
 		copy(f.BrokerID[:], t.BrokerID)
 		copy(f.Password[:], t.passWord)
 		copy(f.UserProductInfo[:], "@HF")
-		t.ReqUserLogin(&f, t.getReqID())
-	} else if t.onRspUserLogin != nil {
+		t.ReqUserLogin(&f, reqID)
+	} else {
 		infoField := (*ctp.CThostFtdcRspInfoField)(unsafe.Pointer(info))
-		t.onRspUserLogin(&RspUserLoginField{}, &RspInfoField{ErrorID: int(infoField.ErrorID), ErrorMsg: Bytes2String(infoField.ErrorMsg[:])})
+		t.rspUserLogin(reqID, &RspUserLoginField{}, &RspInfoField{ErrorID: int(infoField.ErrorID), ErrorMsg: Bytes2String(infoField.ErrorMsg[:])})
 	}
 }
 
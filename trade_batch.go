@@ -0,0 +1,197 @@
+package goctp
+
+import (
+	"fmt"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// OrderFilterType 委托过滤条件
+type OrderFilterType = func(*OrderField) bool
+
+// FilterInstrument 按合约过滤
+func FilterInstrument(instrument string) OrderFilterType {
+	return func(f *OrderField) bool { return f.InstrumentID == instrument }
+}
+
+// FilterInvestor 按帐号过滤
+func FilterInvestor(investor string) OrderFilterType {
+	return func(f *OrderField) bool { return f.InvestorID == investor }
+}
+
+// FilterDirection 按买卖方向过滤
+func FilterDirection(direction DirectionType) OrderFilterType {
+	return func(f *OrderField) bool { return f.Direction == direction }
+}
+
+// FilterExchange 按交易所过滤
+func FilterExchange(exchange string) OrderFilterType {
+	return func(f *OrderField) bool { return f.ExchangeID == exchange }
+}
+
+// SetBatchAction 设置支持批量撤单(ReqBatchOrderAction)的交易所, 未设置的交易所逐笔撤单
+func (t *HFTrade) SetBatchAction(exchanges ...string) {
+	for _, v := range exchanges {
+		t.batchExchanges[v] = struct{}{}
+	}
+}
+
+// WorkingOrders 满足全部条件的挂单, 含已发出尚未确认的委托 (key: sessionID_OrderRef)
+func (t *HFTrade) WorkingOrders(filters ...OrderFilterType) map[string]*OrderField {
+	orders := make(map[string]*OrderField)
+	match := func(key, value interface{}) bool {
+		var f = value.(*OrderField)
+		if f.OrderStatus == OrderStatusAllTraded || f.OrderStatus == OrderStatusCanceled {
+			return true
+		}
+		for _, filter := range filters {
+			if !filter(f) {
+				return true
+			}
+		}
+		orders[key.(string)] = f
+		return true
+	}
+	t.Orders.Range(match)
+	t.sentOrders.Range(match)
+	return orders
+}
+
+// ReqOrderActions 逐笔撤单, 返回发出撤单的数量
+func (t *HFTrade) ReqOrderActions(orderIDs ...string) (cnt int) {
+	for _, id := range orderIDs {
+		if t.ReqOrderAction(id) == 0 {
+			cnt++
+		}
+	}
+	return
+}
+
+// ReqOrderActionBy 撤销满足全部条件的挂单(含尚未确认的委托), 返回撤销的委托数量
+// 本会话在某交易所的挂单全部满足条件且该交易所支持批量撤单时, 以一笔 ReqBatchOrderAction 撤销, 其余逐笔撤单
+func (t *HFTrade) ReqOrderActionBy(filters ...OrderFilterType) int {
+	return t.orderActions(t.ReqBatchAction != nil, filters...)
+}
+
+// ReqOrderActionAll 撤销全部挂单
+func (t *HFTrade) ReqOrderActionAll() int {
+	return t.ReqOrderActionBy()
+}
+
+// ReqBatchOrderAction 批量撤销本会话帐号 investor 在交易所 exchange 的全部挂单, 未实现 ReqBatchAction 时返回 -1
+func (t *HFTrade) ReqBatchOrderAction(investor, exchange string) int {
+	if t.ReqBatchAction == nil {
+		return -1
+	}
+	f := ctp.CThostFtdcInputBatchOrderActionField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], investor)
+	copy(f.ExchangeID[:], exchange)
+	id := t.getReqID()
+	f.OrderActionRef = ctp.TThostFtdcOrderActionRefType(id)
+	f.RequestID = ctp.TThostFtdcRequestIDType(id)
+	f.FrontID = ctp.TThostFtdcFrontIDType(t.frontID)
+	f.SessionID = ctp.TThostFtdcSessionIDType(t.SessionID)
+	t.ReqBatchAction(&f, id)
+	return 0
+}
+
+// RspBatchOrderAction 批量撤单响应(前置校验错误)
+func (t *HFTrade) RspBatchOrderAction(field *ctp.CThostFtdcInputBatchOrderActionField, info *ctp.CThostFtdcRspInfoField) {
+	if info != nil && info.ErrorID != 0 {
+		t.batchActionFailed(Bytes2String(field.InvestorID[:]), Bytes2String(field.ExchangeID[:]), int(field.SessionID), info)
+	}
+}
+
+// ErrRtnBatchOrderAction 批量撤单错误
+func (t *HFTrade) ErrRtnBatchOrderAction(field *ctp.CThostFtdcBatchOrderActionField, info *ctp.CThostFtdcRspInfoField) {
+	t.batchActionFailed(Bytes2String(field.InvestorID[:]), Bytes2String(field.ExchangeID[:]), int(field.SessionID), info)
+}
+
+// batchActionFailed 批量撤单失败时改为逐笔撤单
+func (t *HFTrade) batchActionFailed(investor, exchange string, sessionID int, info *ctp.CThostFtdcRspInfoField) {
+	if !t.IsLogin || sessionID != t.SessionID {
+		return
+	}
+	fmt.Println("batch order action error: ", exchange, " ", Bytes2String(info.ErrorMsg[:]), ", cancel one by one")
+	t.orderActions(false, FilterInvestor(investor), FilterExchange(exchange), func(f *OrderField) bool { return f.SessionID == sessionID })
+}
+
+// orderActions 撤销满足条件的挂单, batch: 是否可批量撤单
+func (t *HFTrade) orderActions(batch bool, filters ...OrderFilterType) (cnt int) {
+	orders := t.WorkingOrders(filters...)
+	if batch {
+		// 本会话的挂单按 帐号_交易所 分组, 组内挂单(含未确认的委托, 确认后亦会被批量撤单)全部满足条件时批量撤单
+		groups := make(map[string]int)
+		group := func(f *OrderField) string { return fmt.Sprintf("%s_%s", f.InvestorID, f.ExchangeID) }
+		for _, f := range orders {
+			if f.SessionID == t.SessionID {
+				groups[group(f)]++
+			}
+		}
+		for _, f := range t.WorkingOrders() {
+			if f.SessionID == t.SessionID {
+				groups[group(f)]--
+			}
+		}
+		batched := make(map[string]map[string]string) // 组 -> 批量撤销的委托 (key: 委托编号, value: InstrumentID)
+		first := make(map[string]*OrderField)         // 组 -> 组内任一委托(帐号/交易所)
+		for key, f := range orders {
+			g := group(f)
+			if _, ok := t.batchExchanges[f.ExchangeID]; !ok || groups[g] != 0 || f.SessionID != t.SessionID {
+				continue
+			}
+			if _, ok := t.Orders.Load(key); !ok { // 未确认的委托逐笔撤单
+				continue
+			}
+			if batched[g] == nil {
+				batched[g] = make(map[string]string)
+				first[g] = f
+			}
+			batched[g][key] = f.InstrumentID
+		}
+		for g, keys := range batched {
+			if !t.statActions(keys) { // 撤单数将超过上限: 逐笔撤单至上限
+				continue
+			}
+			t.ReqBatchOrderAction(first[g].InvestorID, first[g].ExchangeID)
+			for key := range keys {
+				delete(orders, key)
+				cnt++
+			}
+		}
+	}
+	for key := range orders {
+		if t.ReqOrderAction(key) == 0 {
+			cnt++
+		}
+	}
+	return
+}
+
+// orderSent 记录已发出的委托, 以便在确认前撤单
+func (t *HFTrade) orderSent(f *ctp.CThostFtdcInputOrderField) {
+	t.sentOrders.Store(fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:])), &OrderField{
+		InvestorID:          Bytes2String(f.InvestorID[:]),
+		InstrumentID:        Bytes2String(f.InstrumentID[:]),
+		SessionID:           t.SessionID,
+		FrontID:             t.frontID,
+		OrderRef:            Bytes2String(f.OrderRef[:]),
+		Direction:           DirectionType(f.Direction),
+		OffsetFlag:          OffsetFlagType(f.CombOffsetFlag[0]),
+		HedgeFlag:           HedgeFlagType(f.CombHedgeFlag[0]),
+		PriceType:           OrderPriceTypeType(f.OrderPriceType),
+		LimitPrice:          float64(f.LimitPrice),
+		TimeCondition:       TimeConditionType(f.TimeCondition),
+		VolumeCondition:     VolumeConditionType(f.VolumeCondition),
+		MinVolume:           int(f.MinVolume),
+		VolumeTotalOriginal: int(f.VolumeTotalOriginal),
+		VolumeLeft:          int(f.VolumeTotalOriginal),
+		ExchangeID:          Bytes2String(f.ExchangeID[:]),
+		OrderSubmitStatus:   OrderSubmitStatusInsertSubmitted,
+		OrderStatus:         OrderStatusUnknown,
+		StatusMsg:           "委托已发出",
+		IsLocal:             true,
+	})
+}
@@ -0,0 +1,105 @@
+package goctp
+
+// closeTodayExchanges 区分平今/平昨的交易所
+var closeTodayExchanges = map[string]struct{}{"SHFE": {}, "INE": {}}
+
+// SetLockToday 设置平今时以反向开仓锁仓的品种或合约(平今手续费高), ReqClose 平昨后剩余的今仓改为锁仓
+func (t *HFTrade) SetLockToday(products ...string) {
+	for _, v := range products {
+		t.lockProducts[v] = struct{}{}
+	}
+}
+
+// ReqClose 平仓: buySell 为 DirectionSell 时平多, DirectionBuy 时平空, 返回委托编号
+// 按持仓(今仓/昨仓减去平仓挂单)拆分: 上期/能源 平昨+平今, 其他交易所 平仓; 先平昨, 超出可平数量的部分不委托
+func (t *HFTrade) ReqClose(instrument string, buySell DirectionType, price float64, volume int) []string {
+	return t.ReqCloseRequest(NewOrderRequest(instrument, buySell, OffsetFlagClose, price, volume))
+}
+
+// ReqCloseRequest 平仓委托请求, 按持仓拆分并设置 req.OffsetFlag (见 ReqClose)
+func (t *HFTrade) ReqCloseRequest(req *OrderRequest) []string {
+	return t.closeOrders(req, true)
+}
+
+// closeOrders 按持仓拆分平仓, lock 为是否按 SetLockToday 锁仓
+func (t *HFTrade) closeOrders(req *OrderRequest, lock bool) (orderIDs []string) {
+	r := *req
+	if r.HedgeFlag == 0 {
+		r.HedgeFlag = t.hedgeFlag()
+	}
+	yd, today := t.closeAvailable(r.InvestorID, r.InstrumentID, r.Direction, r.HedgeFlag)
+	if yd > r.Volume {
+		yd = r.Volume
+	}
+	if today > r.Volume-yd {
+		today = r.Volume - yd
+	}
+	var exchange, product string
+	if inst, ok := t.Instruments.Load(r.InstrumentID); ok {
+		exchange, product = inst.(*InstrumentField).ExchangeID, inst.(*InstrumentField).ProductID
+	}
+	_, lockInst := t.lockProducts[r.InstrumentID]
+	_, lockProduct := t.lockProducts[product]
+	_, closeToday := closeTodayExchanges[exchange]
+	send := func(offset OffsetFlagType, volume int) {
+		if volume > 0 {
+			o := r
+			o.OffsetFlag, o.Volume = offset, volume
+			orderIDs = append(orderIDs, t.ReqOrderInsertRequest(&o))
+		}
+	}
+	switch {
+	case lock && (lockInst || lockProduct): // 今仓锁仓
+		if closeToday {
+			send(OffsetFlagCloseYesterday, yd)
+		} else {
+			send(OffsetFlagClose, yd)
+		}
+		send(OffsetFlagOpen, today)
+	case closeToday:
+		send(OffsetFlagCloseYesterday, yd)
+		send(OffsetFlagCloseToday, today)
+	default:
+		send(OffsetFlagClose, yd+today)
+	}
+	return
+}
+
+// closeAvailable 可平的昨仓/今仓: 持仓减去平仓挂单(含尚未确认的委托)
+// 上期/能源 平今挂单占用今仓, 其他平仓挂单占用昨仓; 其他交易所先占用昨仓
+func (t *HFTrade) closeAvailable(investor, instrument string, buySell DirectionType, hedge HedgeFlagType) (yd, today int) {
+	var posiDire = PosiDirectionLong
+	if buySell == DirectionBuy {
+		posiDire = PosiDirectionShort
+	}
+	posi, ok := t.positions(investor).Load(PositionKey(instrument, posiDire, hedge))
+	if !ok {
+		return
+	}
+	var p = posi.(*PositionField)
+	if len(investor) == 0 {
+		investor = p.InvestorID
+	}
+	var frozenYd, frozenToday int
+	for _, o := range t.WorkingOrders(FilterInvestor(investor), FilterInstrument(instrument), FilterDirection(buySell)) {
+		if o.OffsetFlag == OffsetFlagOpen || o.HedgeFlag != hedge {
+			continue
+		}
+		if o.OffsetFlag == OffsetFlagCloseToday {
+			frozenToday += o.VolumeLeft
+		} else {
+			frozenYd += o.VolumeLeft
+		}
+	}
+	if _, ok := closeTodayExchanges[p.ExchangeID]; !ok && frozenYd > p.YdPosition { // 先平昨
+		frozenToday += frozenYd - p.YdPosition
+		frozenYd = p.YdPosition
+	}
+	if yd = p.YdPosition - frozenYd; yd < 0 {
+		yd = 0
+	}
+	if today = p.TodayPosition - frozenToday; today < 0 {
+		today = 0
+	}
+	return
+}
@@ -0,0 +1,159 @@
+package goctp
+
+import (
+	"fmt"
+	"strings"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// ReqCombActionInsert 组合/拆分持仓(大商所/郑商所), instrument 为组合合约如 SP a2301&a2305, buySell 为第一腿方向. 返回 sessionID_CombActionRef
+func (t *HFTrade) ReqCombActionInsert(instrument string, buySell DirectionType, combDirection CombDirectionType, volume int) string {
+	f := ctp.CThostFtdcInputCombActionField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	if info, ok := t.Instruments.Load(instrument); ok {
+		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
+	}
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], t.InvestorID)
+	// 参数赋值
+	id := t.getReqID()
+	copy(f.CombActionRef[:], fmt.Sprintf("%012d", id))
+	copy(f.InstrumentID[:], instrument)
+	f.Direction = ctp.TThostFtdcDirectionType(buySell)
+	f.Volume = ctp.TThostFtdcVolumeType(volume)
+	f.CombDirection = ctp.TThostFtdcCombDirectionType(combDirection)
+	f.HedgeFlag = ctp.TThostFtdcHedgeFlagType(t.hedgeFlag())
+	t.ReqCombAction(&f, id)
+	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.CombActionRef[:]))
+}
+
+// ReqQryCombAction 查询组合指令, 结果更新至 CombActions
+func (t *HFTrade) ReqQryCombAction() {
+	f := ctp.CThostFtdcQryCombActionField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.InvestorID[:], t.InvestorID)
+	t.ReqQryComb(&f, t.getReqID())
+}
+
+// RegOnRtnCombAction 注册组合指令响应(提交及状态变化)
+func (t *HFTrade) RegOnRtnCombAction(on OnRtnCombActionType) {
+	t.onRtnCombAction = on
+}
+
+// RegOnErrRtnCombAction 注册组合指令错误响应
+func (t *HFTrade) RegOnErrRtnCombAction(on OnRtnErrCombActionType) {
+	t.onErrRtnCombAction = on
+}
+
+// RtnCombAction 组合指令响应
+func (t *HFTrade) RtnCombAction(field *ctp.CThostFtdcCombActionField) {
+	if _, exists := t.Investors[Bytes2String(field.InvestorID[:])]; !exists {
+		return
+	}
+	key := fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.CombActionRef[:]))
+	of, _ := t.CombActions.LoadOrStore(key, &CombActionField{
+		InvestorID:    Bytes2String(field.InvestorID[:]),
+		InstrumentID:  Bytes2String(field.InstrumentID[:]),
+		ExchangeID:    Bytes2String(field.ExchangeID[:]),
+		CombActionRef: Bytes2String(field.CombActionRef[:]),
+		Direction:     DirectionType(field.Direction),
+		Volume:        int(field.Volume),
+		CombDirection: CombDirectionType(field.CombDirection),
+		HedgeFlag:     HedgeFlagType(field.HedgeFlag),
+		ActionStatus:  CombActionStatusSubmitted,
+		FrontID:       int(field.FrontID),
+		SessionID:     int(field.SessionID),
+		IsLocal:       int(field.SessionID) == t.SessionID,
+	})
+	var f = of.(*CombActionField)
+	status := CombActionStatusType(field.ActionStatus)
+	accepted := status == CombActionStatusAccepted && f.ActionStatus != CombActionStatusAccepted
+	f.ActionStatus = status
+	f.StatusMsg = Bytes2String(field.StatusMsg[:])
+	if id := Bytes2String(field.ComTradeID[:]); len(id) > 0 {
+		f.ComTradeID = id
+	}
+	if !t.IsLogin { // 登录前不响应(持仓查询结果已包含)
+		return
+	}
+	if accepted {
+		t.combPosition(f)
+	}
+	if status == CombActionStatusRejected {
+		if t.onErrRtnCombAction != nil {
+			t.onErrRtnCombAction(f, &RspInfoField{
+				ErrorID:  -1,
+				ErrorMsg: f.StatusMsg,
+			})
+		}
+	} else if t.onRtnCombAction != nil {
+		t.onRtnCombAction(f)
+	}
+}
+
+// ErrRtnCombActionInsert 组合指令错误
+func (t *HFTrade) ErrRtnCombActionInsert(field *ctp.CThostFtdcInputCombActionField, info *ctp.CThostFtdcRspInfoField) {
+	if !t.IsLogin { // 过滤当日以前登录时的错误
+		return
+	}
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.CombActionRef[:]))
+	of, _ := t.CombActions.LoadOrStore(key, &CombActionField{
+		InvestorID:    Bytes2String(field.InvestorID[:]),
+		InstrumentID:  Bytes2String(field.InstrumentID[:]),
+		ExchangeID:    Bytes2String(field.ExchangeID[:]),
+		CombActionRef: Bytes2String(field.CombActionRef[:]),
+		Direction:     DirectionType(field.Direction),
+		Volume:        int(field.Volume),
+		CombDirection: CombDirectionType(field.CombDirection),
+		HedgeFlag:     HedgeFlagType(field.HedgeFlag),
+		SessionID:     t.SessionID,
+		IsLocal:       true,
+	})
+	var f = of.(*CombActionField)
+	f.ActionStatus = CombActionStatusRejected
+	f.StatusMsg = Bytes2String(info.ErrorMsg[:])
+	if t.onErrRtnCombAction != nil {
+		t.onErrRtnCombAction(f, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+	}
+}
+
+// RspQryCombAction 查组合指令响应
+func (t *HFTrade) RspQryCombAction(field *ctp.CThostFtdcCombActionField) {
+	if len(Bytes2String(field.InvestorID[:])) > 0 { // 处理无组合指令时的空响应
+		t.RtnCombAction(field)
+	}
+}
+
+// combPosition 组合/拆分成功后修正两腿持仓的组合持仓量(随后的持仓查询会进行修正)
+func (t *HFTrade) combPosition(f *CombActionField) {
+	legs := combLegs(f.InstrumentID)
+	if len(legs) != 2 {
+		return
+	}
+	volume := f.Volume
+	if f.CombDirection != CombDirectionComb {
+		volume = -volume
+	}
+	// 买组合: 第一腿多头 第二腿空头; 卖组合相反
+	sides := [2]PosiDirectionType{PosiDirectionLong, PosiDirectionShort}
+	if f.Direction == DirectionSell {
+		sides = [2]PosiDirectionType{PosiDirectionShort, PosiDirectionLong}
+	}
+	for i, leg := range legs {
+		if p, ok := t.Positions.Load(PositionKey(leg, sides[i], f.HedgeFlag)); ok {
+			p.(*PositionField).CombPosition += volume
+		}
+	}
+}
+
+// combLegs 组合合约的两腿, 如 SP a2301&a2305 -> [a2301 a2305]
+func combLegs(instrument string) []string {
+	if i := strings.LastIndex(instrument, " "); i >= 0 {
+		instrument = instrument[i+1:]
+	}
+	if !strings.Contains(instrument, "&") {
+		return nil
+	}
+	return strings.Split(instrument, "&")
+}
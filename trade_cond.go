@@ -0,0 +1,247 @@
+package goctp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// ReqCondOrderInsert 本地条件单: 行情满足 req.ContingentCondition/req.StopPrice 时发出委托, 返回条件单编号
+// 止损(ContingentConditionTouch): 买 最新价>=止损价, 卖 最新价<=止损价; 止赢(ContingentConditionTouchProfit)相反.
+// req.ContingentCondition 为 0 时按止损处理; 限价且 req.LimitPrice 为 0 时以触发时的对手价委托
+func (t *HFTrade) ReqCondOrderInsert(req *OrderRequest) string {
+	return t.condInsert(req, 0)
+}
+
+// ReqTrailingStopInsert 本地跟踪止损: 卖(平多)时止损价随最新价上移并保持 distance, 最新价<=止损价时委托; 买(平空)相反.
+// req.StopPrice 为 0 时由首笔行情确定
+func (t *HFTrade) ReqTrailingStopInsert(req *OrderRequest, distance float64) string {
+	r := *req
+	r.ContingentCondition = ContingentConditionTouch
+	return t.condInsert(&r, distance)
+}
+
+// ReqCondOrderAction 撤销本地条件单, 条件单不存在或已触发时返回 -1
+func (t *HFTrade) ReqCondOrderAction(condOrderID string) int {
+	t.condMu.Lock()
+	c, ok := t.CondOrders.Load(condOrderID)
+	if !ok || c.(*CondOrderField).Status != CondOrderStatusPending {
+		t.condMu.Unlock()
+		return -1
+	}
+	var f = c.(*CondOrderField)
+	f.Status = CondOrderStatusCanceled
+	f.CancelTime = time.Now().Local().Format("15:04:05")
+	t.condMu.Unlock()
+	t.saveCondOrders()
+	if t.onRtnCondOrder != nil {
+		t.onRtnCondOrder(f)
+	}
+	return 0
+}
+
+// RegOnRtnCondOrder 注册本地条件单响应(触发/撤销)
+func (t *HFTrade) RegOnRtnCondOrder(on OnRtnCondOrderType) {
+	t.onRtnCondOrder = on
+}
+
+// AttachQuote 挂接行情接口(替代 RegOnTick), 先更新最新行情(风控)并处理本地条件单再调用 on(可为 nil)
+func (t *HFTrade) AttachQuote(q *HFQuote, on OnTickType) {
+	q.RegOnTick(func(tick *TickField) {
+		t.UpdateTick(tick)
+		if on != nil {
+			on(tick)
+		}
+	})
+}
+
+// UpdateCondOrders 以行情驱动本地条件单(未使用 AttachQuote 时在 RegOnTick 中调用)
+func (t *HFTrade) UpdateCondOrders(tick *TickField) {
+	if !t.IsLogin {
+		return
+	}
+	var fired []*CondOrderField
+	var moved bool
+	t.condMu.Lock()
+	t.CondOrders.Range(func(_, value interface{}) bool {
+		var f = value.(*CondOrderField)
+		if f.Status != CondOrderStatusPending || f.Request.InstrumentID != tick.InstrumentID {
+			return true
+		}
+		stop := f.Request.StopPrice
+		if condTriggered(f, tick) {
+			f.Status = CondOrderStatusTriggered
+			f.TriggerTime = tick.UpdateTime
+			fired = append(fired, f)
+		} else if f.Request.StopPrice != stop { // 跟踪止损价变化
+			moved = true
+		}
+		return true
+	})
+	t.condMu.Unlock()
+	for _, f := range fired {
+		r := f.Request
+		r.ContingentCondition = ContingentConditionImmediately
+		r.StopPrice = 0
+		if r.LimitPrice == 0 && (r.PriceType == 0 || r.PriceType == OrderPriceTypeLimitPrice) { // 对手价
+			r.LimitPrice = tick.LastPrice
+			if r.Direction == DirectionBuy && ValidPrice(tick.AskPrice1) {
+				r.LimitPrice = tick.AskPrice1
+			} else if r.Direction == DirectionSell && ValidPrice(tick.BidPrice1) {
+				r.LimitPrice = tick.BidPrice1
+			}
+		}
+		id := t.ReqOrderInsertRequest(&r)
+		t.condMu.Lock() // saveCondOrders 在 condMu 下序列化
+		f.OrderID = id
+		t.condMu.Unlock()
+		if t.onRtnCondOrder != nil {
+			t.onRtnCondOrder(f)
+		}
+	}
+	if len(fired) > 0 {
+		t.saveCondOrders()
+	} else if moved {
+		t.saveCondOrdersLater()
+	}
+}
+
+// SetCondOrderFile 本地条件单保存至文件(json), 并加载文件中未触发的条件单, 重启后恢复
+func (t *HFTrade) SetCondOrderFile(path string) error {
+	t.condFile = path
+	bs, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var fields []*CondOrderField
+	if err = json.Unmarshal(bs, &fields); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.Status == CondOrderStatusPending {
+			if f.Request.ContingentCondition == 0 {
+				f.Request.ContingentCondition = ContingentConditionTouch
+			}
+			t.CondOrders.LoadOrStore(f.CondOrderID, f)
+		}
+	}
+	return nil
+}
+
+func (t *HFTrade) condInsert(req *OrderRequest, distance float64) string {
+	r := *req
+	if len(r.InvestorID) == 0 {
+		r.InvestorID = t.InvestorID
+	}
+	if r.ContingentCondition == 0 { // 未指定时为止损
+		r.ContingentCondition = ContingentConditionTouch
+	}
+	t.condMu.Lock()
+	if t.condID == 0 { // 以启动时间为起点, 重启后不与文件中的编号重复
+		t.condID = time.Now().UnixNano()
+	}
+	t.condID++
+	f := &CondOrderField{
+		CondOrderID:   fmt.Sprintf("cond_%d", t.condID),
+		Request:       r,
+		TrailDistance: distance,
+		Status:        CondOrderStatusPending,
+		TradingDay:    t.TradingDay,
+		InsertTime:    time.Now().Local().Format("15:04:05"),
+	}
+	t.CondOrders.Store(f.CondOrderID, f)
+	t.condMu.Unlock()
+	t.saveCondOrders()
+	return f.CondOrderID
+}
+
+// saveCondOrders 保存未触发及当日的条件单
+func (t *HFTrade) saveCondOrders() {
+	if len(t.condFile) == 0 {
+		return
+	}
+	t.condMu.Lock()
+	defer t.condMu.Unlock()
+	fields := make([]*CondOrderField, 0)
+	t.CondOrders.Range(func(_, value interface{}) bool {
+		var f = value.(*CondOrderField)
+		if f.Status == CondOrderStatusPending || f.TradingDay == t.TradingDay {
+			fields = append(fields, f)
+		}
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].CondOrderID < fields[j].CondOrderID })
+	bs, err := json.MarshalIndent(fields, "", "  ")
+	if err == nil {
+		tmp := t.condFile + ".tmp"
+		if err = os.WriteFile(tmp, bs, 0644); err == nil {
+			err = os.Rename(tmp, t.condFile)
+		}
+	}
+	if err != nil {
+		fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " save cond orders: ", err)
+	}
+}
+
+// saveCondOrdersLater 跟踪止损价变化时延迟 1 秒保存, 合并频繁的写文件
+func (t *HFTrade) saveCondOrdersLater() {
+	if len(t.condFile) == 0 {
+		return
+	}
+	t.condMu.Lock()
+	defer t.condMu.Unlock()
+	if t.condSave != nil { // 已在等待保存
+		return
+	}
+	t.condSave = time.AfterFunc(time.Second, func() {
+		t.condMu.Lock()
+		t.condSave = nil
+		t.condMu.Unlock()
+		t.saveCondOrders()
+	})
+}
+
+// condTriggered 条件是否满足, 跟踪止损时先移动止损价
+func condTriggered(f *CondOrderField, tick *TickField) bool {
+	var r = &f.Request
+	buy := r.Direction == DirectionBuy
+	if f.TrailDistance > 0 && ValidPrice(tick.LastPrice) {
+		if stop := tick.LastPrice + f.TrailDistance; buy && (r.StopPrice == 0 || stop < r.StopPrice) {
+			r.StopPrice = stop
+		} else if stop := tick.LastPrice - f.TrailDistance; !buy && stop > r.StopPrice {
+			r.StopPrice = stop
+		}
+	}
+	var price float64
+	switch r.ContingentCondition {
+	case ContingentConditionAskPriceGreaterThanStopPrice, ContingentConditionAskPriceGreaterEqualStopPrice, ContingentConditionAskPriceLesserThanStopPrice, ContingentConditionAskPriceLesserEqualStopPrice:
+		price = tick.AskPrice1
+	case ContingentConditionBidPriceGreaterThanStopPrice, ContingentConditionBidPriceGreaterEqualStopPrice, ContingentConditionBidPriceLesserThanStopPrice, ContingentConditionBidPriceLesserEqualStopPrice:
+		price = tick.BidPrice1
+	default:
+		price = tick.LastPrice
+	}
+	if !ValidPrice(price) || r.StopPrice == 0 {
+		return false
+	}
+	stop := r.StopPrice
+	switch r.ContingentCondition {
+	case ContingentConditionTouch:
+		return buy && price >= stop || !buy && price <= stop
+	case ContingentConditionTouchProfit:
+		return buy && price <= stop || !buy && price >= stop
+	case ContingentConditionLastPriceGreaterThanStopPrice, ContingentConditionAskPriceGreaterThanStopPrice, ContingentConditionBidPriceGreaterThanStopPrice:
+		return price > stop
+	case ContingentConditionLastPriceGreaterEqualStopPrice, ContingentConditionAskPriceGreaterEqualStopPrice, ContingentConditionBidPriceGreaterEqualStopPrice:
+		return price >= stop
+	case ContingentConditionLastPriceLesserThanStopPrice, ContingentConditionAskPriceLesserThanStopPrice, ContingentConditionBidPriceLesserThanStopPrice:
+		return price < stop
+	case ContingentConditionLastPriceLesserEqualStopPrice, ContingentConditionAskPriceLesserEqualStopPrice, ContingentConditionBidPriceLesserEqualStopPrice:
+		return price <= stop
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package goctp
+
+import (
+	"fmt"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// ReqExecOrderInsert 期权行权, 返回 sessionID_ExecOrderRef
+func (t *HFTrade) ReqExecOrderInsert(instrument string, volume int) string {
+	return t.reqExecOrder(instrument, volume, ExecActionExec)
+}
+
+// ReqExecOrderAbandon 放弃行权(如实值期权到期不行权), 返回 sessionID_ExecOrderRef
+func (t *HFTrade) ReqExecOrderAbandon(instrument string, volume int) string {
+	return t.reqExecOrder(instrument, volume, ExecActionAbandon)
+}
+
+func (t *HFTrade) reqExecOrder(instrument string, volume int, actionType ExecActionType) string {
+	f := ctp.CThostFtdcInputExecOrderField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	if info, ok := t.Instruments.Load(instrument); ok {
+		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
+	}
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], t.InvestorID)
+	copy(f.AccountID[:], t.InvestorID)
+	// 参数赋值
+	id := t.getReqID()
+	copy(f.ExecOrderRef[:], fmt.Sprintf("%012d", id))
+	copy(f.InstrumentID[:], instrument)
+	f.RequestID = ctp.TThostFtdcRequestIDType(id)
+	f.Volume = ctp.TThostFtdcVolumeType(volume)
+	f.ActionType = ctp.TThostFtdcActionTypeType(actionType)
+	f.OffsetFlag = ctp.TThostFtdcOffsetFlagType(OffsetFlagClose)
+	f.HedgeFlag = ctp.TThostFtdcHedgeFlagType(t.hedgeFlag())
+	f.PosiDirection = ctp.THOST_FTDC_PD_Long              // 买方持仓
+	f.ReservePositionFlag = ctp.THOST_FTDC_EOPF_UnReserve // 已废弃
+	f.CloseFlag = ctp.THOST_FTDC_EOCF_NotToClose          // 行权后保留期货头寸
+	t.ReqExecOrder(&f, id)
+	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.ExecOrderRef[:]))
+}
+
+// ReqExecOrderAction 撤销执行宣告
+func (t *HFTrade) ReqExecOrderAction(execOrderID string) int {
+	if o, ok := t.ExecOrders.Load(execOrderID); ok {
+		var order = o.(*ExecOrderField)
+		f := ctp.CThostFtdcInputExecOrderActionField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		copy(f.UserID[:], t.UserID)
+		copy(f.InvestorID[:], order.InvestorID)
+		copy(f.InstrumentID[:], order.InstrumentID)
+		copy(f.ExchangeID[:], order.ExchangeID)
+		copy(f.ExecOrderRef[:], order.ExecOrderRef)
+		f.ActionFlag = ctp.THOST_FTDC_AF_Delete
+		f.FrontID = ctp.TThostFtdcFrontIDType(order.FrontID)
+		f.SessionID = ctp.TThostFtdcSessionIDType(order.SessionID)
+		t.ReqExecAction(&f, t.getReqID())
+		return 0
+	}
+	return -1
+}
+
+// RegOnRtnExecOrder 注册执行宣告响应(提交及状态变化)
+func (t *HFTrade) RegOnRtnExecOrder(on OnRtnExecOrderType) {
+	t.onRtnExecOrder = on
+}
+
+// RegOnErrRtnExecOrder 注册执行宣告错误响应
+func (t *HFTrade) RegOnErrRtnExecOrder(on OnRtnErrExecOrderType) {
+	t.onErrRtnExecOrder = on
+}
+
+// RegOnErrExecAction 注册撤销执行宣告错误响应
+func (t *HFTrade) RegOnErrExecAction(on OnRtnErrActionType) {
+	t.onErrExecAction = on
+}
+
+// RtnExecOrder 执行宣告响应
+func (t *HFTrade) RtnExecOrder(field *ctp.CThostFtdcExecOrderField) {
+	if _, exists := t.Investors[Bytes2String(field.InvestorID[:])]; !exists {
+		return
+	}
+	key := fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.ExecOrderRef[:]))
+	of, _ := t.ExecOrders.LoadOrStore(key, &ExecOrderField{
+		InvestorID:   Bytes2String(field.InvestorID[:]),
+		InstrumentID: Bytes2String(field.InstrumentID[:]),
+		ExecOrderRef: Bytes2String(field.ExecOrderRef[:]),
+		ExchangeID:   Bytes2String(field.ExchangeID[:]),
+		ActionType:   ExecActionType(field.ActionType),
+		Volume:       int(field.Volume),
+		InsertDate:   Bytes2String(field.InsertDate[:]),
+		InsertTime:   Bytes2String(field.InsertTime[:]),
+		FrontID:      int(field.FrontID),
+		SessionID:    int(field.SessionID),
+		IsLocal:      int(field.SessionID) == t.SessionID,
+	})
+	var f = of.(*ExecOrderField)
+	f.ExecResult = ExecResultType(field.ExecResult)
+	f.StatusMsg = Bytes2String(field.StatusMsg[:])
+	f.CancelTime = Bytes2String(field.CancelTime[:])
+	if sysID := Bytes2String(field.ExecOrderSysID[:]); len(sysID) > 0 {
+		f.ExecOrderSysID = sysID
+	}
+	if !t.IsLogin { // 登录前不响应
+		return
+	}
+	if field.OrderSubmitStatus == ctp.THOST_FTDC_OSS_InsertRejected { // 交易所拒绝
+		f.ExecResult = ExecResultCanceled
+		if t.onErrRtnExecOrder != nil {
+			t.onErrRtnExecOrder(f, &RspInfoField{
+				ErrorID:  -1,
+				ErrorMsg: f.StatusMsg,
+			})
+		}
+	} else if t.onRtnExecOrder != nil {
+		t.onRtnExecOrder(f)
+	}
+}
+
+// ErrRtnExecOrderInsert 执行宣告错误
+func (t *HFTrade) ErrRtnExecOrderInsert(field *ctp.CThostFtdcInputExecOrderField, info *ctp.CThostFtdcRspInfoField) {
+	if !t.IsLogin { // 过滤当日以前登录时的错误
+		return
+	}
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.ExecOrderRef[:]))
+	of, _ := t.ExecOrders.LoadOrStore(key, &ExecOrderField{
+		InvestorID:   Bytes2String(field.InvestorID[:]),
+		InstrumentID: Bytes2String(field.InstrumentID[:]),
+		ExecOrderRef: Bytes2String(field.ExecOrderRef[:]),
+		ExchangeID:   Bytes2String(field.ExchangeID[:]),
+		ActionType:   ExecActionType(field.ActionType),
+		Volume:       int(field.Volume),
+		SessionID:    t.SessionID,
+		IsLocal:      true,
+	})
+	var f = of.(*ExecOrderField)
+	f.ExecResult = ExecResultCanceled
+	f.StatusMsg = Bytes2String(info.ErrorMsg[:])
+	if t.onErrRtnExecOrder != nil {
+		t.onErrRtnExecOrder(f, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+	}
+}
+
+// ErrRtnExecOrderAction 撤销执行宣告错误
+func (t *HFTrade) ErrRtnExecOrderAction(field *ctp.CThostFtdcExecOrderActionField, info *ctp.CThostFtdcRspInfoField) {
+	if t.IsLogin && t.onErrExecAction != nil {
+		t.onErrExecAction(fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.ExecOrderRef[:])), &RspInfoField{
+			ErrorID:  int(info.ErrorID),
+			ErrorMsg: Bytes2String(info.ErrorMsg[:]),
+		})
+	}
+}
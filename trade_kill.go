@@ -0,0 +1,161 @@
+package goctp
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// killTicks 无涨跌停价时平仓价为最新价加(买)/减(卖)的最小变动价位数
+const killTicks = 10
+
+// KillSwitch 撤销全部挂单并平掉全部持仓, 阻塞至全部平仓或超时, 返回结果
+// 平仓按持仓拆分平今/平昨(不锁仓), 以涨跌停价委托; 无行情(UpdateTick)时先查询行情(ReqQryDepth), 无涨跌停价时以最新价加减 10 个价位委托; 未成交的平仓委托每秒撤单重发.
+// 执行期间拒绝开仓委托, 平仓委托及撤单不经风控/自成交/撤单上限检查. 交易员模式(多帐号)每轮等待持仓查询刷新后再平仓
+func (t *HFTrade) KillSwitch(timeout time.Duration) *KillSummaryField {
+	sum := &KillSummaryField{StartTime: time.Now().Local().Format("15:04:05")}
+	deadline := time.Now().Add(timeout)
+	atomic.StoreInt32(&t.killing, 1)
+	defer atomic.StoreInt32(&t.killing, 0)
+	for {
+		sum.Rounds++
+		sum.Canceled += t.ReqOrderActionAll()
+		t.killWait(deadline)
+		if len(t.Investors) > 1 { // 交易员模式的持仓不随成交更新
+			t.killRefresh(deadline)
+		}
+		positions := t.openPositions()
+		if len(positions) == 0 {
+			sum.Flat = true
+			break
+		}
+		if !time.Now().Before(deadline) {
+			for _, p := range positions {
+				sum.Positions = append(sum.Positions, *p)
+			}
+			break
+		}
+		for _, p := range positions {
+			sum.Orders = append(sum.Orders, t.killClose(p)...)
+		}
+		wait := time.Now().Add(time.Second)
+		if wait.After(deadline) {
+			wait = deadline
+		}
+		t.killWait(wait)
+	}
+	for _, id := range sum.Orders {
+		if o, ok := t.Orders.Load(id); ok {
+			sum.Closed += o.(*OrderField).VolumeTotalOriginal - o.(*OrderField).VolumeLeft
+		}
+	}
+	sum.EndTime = time.Now().Local().Format("15:04:05")
+	return sum
+}
+
+// killClose 以涨跌停价(无涨跌停价时最新价加减 killTicks 个价位)平仓, 无价格时不平仓
+func (t *HFTrade) killClose(p *PositionField) []string {
+	var buySell = DirectionSell
+	if p.PositionDirection == PosiDirectionShort {
+		buySell = DirectionBuy
+	}
+	req := NewOrderRequest(p.InstrumentID, buySell, OffsetFlagClose, 0, p.Position).SetInvestor(p.InvestorID).SetHedgeFlag(p.HedgeFlag)
+	tick := t.killTick(p.InstrumentID)
+	if tick != nil && ValidPrice(tick.UpperLimitPrice) && ValidPrice(tick.LowerLimitPrice) {
+		req.LimitPrice = tick.LowerLimitPrice
+		if buySell == DirectionBuy {
+			req.LimitPrice = tick.UpperLimitPrice
+		}
+	} else if inst, ok := t.Instruments.Load(p.InstrumentID); ok && tick != nil && ValidPrice(tick.LastPrice) && inst.(*InstrumentField).PriceTick > 0 {
+		req.LimitPrice = tick.LastPrice - killTicks*inst.(*InstrumentField).PriceTick
+		if buySell == DirectionBuy {
+			req.LimitPrice = tick.LastPrice + killTicks*inst.(*InstrumentField).PriceTick
+		}
+	} else {
+		fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " kill switch: no price for ", p.InstrumentID)
+		return nil
+	}
+	return t.closeOrders(req, false)
+}
+
+// killTick 合约最新行情, 无行情时查询(ReqQryDepth, 最多等待 1 秒)
+func (t *HFTrade) killTick(instrument string) *TickField {
+	if tick, ok := t.ticks.Load(instrument); ok {
+		return tick.(*TickField)
+	}
+	if t.ReqQryDepth == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second) // 经查询队列, 可能需等待前一查询
+	defer cancel()
+	id := t.getReqID()
+	f := ctp.CThostFtdcQryDepthMarketDataField{}
+	copy(f.InstrumentID[:], instrument)
+	var qerr error
+	t.wait(ctx, id, func() {
+		qerr = t.qry(func() { t.ReqQryDepth(&f, id) })
+	}, func(info *RspInfoField) bool { return qerr != nil || info != nil })
+	if tick, ok := t.ticks.Load(instrument); ok {
+		return tick.(*TickField)
+	}
+	return nil
+}
+
+// RspQryDepthMarketData 查询行情响应, 无行情(UpdateTick)的合约以查询结果更新
+func (t *HFTrade) RspQryDepthMarketData(field *ctp.CThostFtdcDepthMarketDataField, info *ctp.CThostFtdcRspInfoField, reqID int, b bool) {
+	if instrument := Bytes2String(field.InstrumentID[:]); len(instrument) > 0 {
+		t.ticks.LoadOrStore(instrument, &TickField{
+			TradingDay:      Bytes2String(field.TradingDay[:]),
+			InstrumentID:    instrument,
+			ExchangeID:      Bytes2String(field.ExchangeID[:]),
+			LastPrice:       float64(field.LastPrice),
+			UpperLimitPrice: float64(field.UpperLimitPrice),
+			LowerLimitPrice: float64(field.LowerLimitPrice),
+			BidPrice1:       float64(field.BidPrice1),
+			AskPrice1:       float64(field.AskPrice1),
+			UpdateTime:      Bytes2String(field.UpdateTime[:]),
+			ActionDay:       Bytes2String(field.ActionDay[:]),
+		})
+	}
+	if b {
+		t.qryFinished()
+		t.notify(reqID, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+	}
+}
+
+// openPositions 未平的多/空持仓(交易员模式为全部帐号)
+func (t *HFTrade) openPositions() (positions []*PositionField) {
+	add := func(_, value interface{}) bool {
+		var p = value.(*PositionField)
+		if p.Position > 0 && p.PositionDirection != PosiDirectionNet {
+			positions = append(positions, p)
+		}
+		return true
+	}
+	if len(t.Investors) > 1 {
+		for _, ps := range t.UserPositions {
+			ps.Range(add)
+		}
+	} else {
+		t.Positions.Range(add)
+	}
+	return
+}
+
+// killWait 等待挂单全部完成, 最迟至 until
+func (t *HFTrade) killWait(until time.Time) {
+	for time.Now().Before(until) && len(t.WorkingOrders()) > 0 {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// killRefresh 等待此后发出的持仓查询完成, 最迟至 until
+func (t *HFTrade) killRefresh(until time.Time) {
+	mark := atomic.LoadInt64(&t.posiQry)
+	for time.Now().Before(until) && atomic.LoadInt64(&t.posiDone) <= mark {
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,150 @@
+package goctp
+
+import (
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// orderModify 改单请求
+type orderModify struct {
+	price     float64
+	volume    int
+	native    bool // 原生改单
+	traded    int  // 请求时已成交数量
+	total     int  // 原生改单后的委托数量
+	actionRef int  // 撤单/改单指令的 OrderActionRef
+}
+
+// SetModifyAction 设置支持原生改单(ActionFlag Modify)的交易所, 其余交易所以撤单后重新委托的方式改单
+func (t *HFTrade) SetModifyAction(exchanges ...string) {
+	for _, v := range exchanges {
+		t.modifyExchanges[v] = struct{}{}
+	}
+}
+
+// RegOnRtnOrderModify 注册改单响应
+func (t *HFTrade) RegOnRtnOrderModify(on OnRtnOrderModifyType) {
+	t.onRtnOrderModify = on
+}
+
+// ReqOrderModify 改单, volume<=0 时保持剩余数量. 返回 0: 已发出 -1: 委托不存在或已完成 -2: 已有改单在处理 -3: 撤单数达到上限 -4: 价格与数量均未变化
+// 交易所不支持原生改单时先撤单, 撤单成功后以 price 重新委托 volume 扣除请求后成交的数量, 新委托通过 RegOnRtnOrderModify 响应
+func (t *HFTrade) ReqOrderModify(orderID string, price float64, volume int) int {
+	o, ok := t.Orders.Load(orderID)
+	if !ok { // 尚未确认的委托
+		o, ok = t.sentOrders.Load(orderID)
+	}
+	if !ok {
+		return -1
+	}
+	var order = o.(*OrderField)
+	if order.OrderStatus == OrderStatusAllTraded || order.OrderStatus == OrderStatusCanceled {
+		return -1
+	}
+	if price == order.LimitPrice && (volume <= 0 || volume == order.VolumeLeft) {
+		return -4
+	}
+	_, native := t.modifyExchanges[order.ExchangeID]
+	modify := &orderModify{
+		price:     price,
+		volume:    volume,
+		native:    native,
+		traded:    order.VolumeTotalOriginal - order.VolumeLeft,
+		total:     order.VolumeTotalOriginal,
+		actionRef: t.getReqID(),
+	}
+	if native && volume > 0 {
+		modify.total += volume - order.VolumeLeft
+	}
+	if _, loaded := t.modifies.LoadOrStore(orderID, modify); loaded {
+		return -2
+	}
+	if !native {
+		if t.reqOrderAction(orderID, modify.actionRef) != 0 {
+			t.modifies.Delete(orderID)
+			return -3
+		}
+		return 0
+	}
+	f := ctp.CThostFtdcInputOrderActionField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], order.InvestorID)
+	copy(f.InstrumentID[:], order.InstrumentID)
+	copy(f.ExchangeID[:], order.ExchangeID)
+	copy(f.OrderRef[:], order.OrderRef)
+	f.ActionFlag = ctp.THOST_FTDC_AF_Modify
+	f.LimitPrice = ctp.TThostFtdcPriceType(price)
+	if volume > 0 {
+		f.VolumeChange = ctp.TThostFtdcVolumeType(volume - order.VolumeLeft)
+	}
+	f.FrontID = ctp.TThostFtdcFrontIDType(order.FrontID)
+	f.SessionID = ctp.TThostFtdcSessionIDType(order.SessionID)
+	f.OrderActionRef = ctp.TThostFtdcOrderActionRefType(modify.actionRef)
+	t.ReqAction(&f, modify.actionRef)
+	return 0
+}
+
+// modifyCanceled 改单的撤单成功后重新委托
+func (t *HFTrade) modifyCanceled(key string, f *OrderField) bool {
+	m, ok := t.modifies.Load(key)
+	if !ok {
+		return false
+	}
+	t.modifies.Delete(key)
+	var modify = m.(*orderModify)
+	if modify.native || orderRejected(f) { // 原生改单期间被撤销或委托被拒绝
+		if t.onRtnOrderModify != nil {
+			t.onRtnOrderModify(key, "", &RspInfoField{ErrorID: -1, ErrorMsg: f.StatusMsg})
+		}
+		return false
+	}
+	volume := f.VolumeLeft
+	if modify.volume > 0 { // 扣除请求后成交的数量
+		volume = modify.volume - (f.VolumeTotalOriginal - f.VolumeLeft - modify.traded)
+	}
+	if volume <= 0 { // 撤单前已成交完目标数量
+		if t.onRtnOrderModify != nil {
+			t.onRtnOrderModify(key, "", &RspInfoField{ErrorID: 0, ErrorMsg: "成功"})
+		}
+		return false
+	}
+	req := NewOrderRequest(f.InstrumentID, f.Direction, f.OffsetFlag, modify.price, volume).SetInvestor(f.InvestorID).SetHedgeFlag(f.HedgeFlag).
+		SetPriceType(f.PriceType).SetTimeCondition(f.TimeCondition)
+	req.VolumeCondition, req.MinVolume = f.VolumeCondition, f.MinVolume // 保持原委托的成交量条件
+	newID := t.ReqOrderInsertRequest(req)
+	if t.onRtnOrderModify != nil {
+		t.onRtnOrderModify(key, newID, &RspInfoField{ErrorID: 0, ErrorMsg: "成功"})
+	}
+	return true
+}
+
+// modifyUpdated 原生改单后交易所推送新的价格/数量
+func (t *HFTrade) modifyUpdated(key string, f *OrderField, field *ctp.CThostFtdcOrderField) {
+	m, ok := t.modifies.Load(key)
+	if !ok {
+		return
+	}
+	var modify = m.(*orderModify)
+	if !modify.native || float64(field.LimitPrice) != modify.price || int(field.VolumeTotalOriginal) != modify.total {
+		return
+	}
+	t.modifies.Delete(key)
+	f.LimitPrice = float64(field.LimitPrice)
+	f.VolumeTotalOriginal = int(field.VolumeTotalOriginal)
+	f.VolumeLeft = int(field.VolumeTotal)
+	if t.onRtnOrderModify != nil {
+		t.onRtnOrderModify(key, key, &RspInfoField{ErrorID: 0, ErrorMsg: "成功"})
+	}
+}
+
+// modifyFailed 改单的撤单/改单指令(按 OrderActionRef 对应)被拒绝
+func (t *HFTrade) modifyFailed(key string, actionRef int, info *RspInfoField) bool {
+	if m, ok := t.modifies.Load(key); !ok || m.(*orderModify).actionRef != actionRef {
+		return false
+	}
+	t.modifies.Delete(key)
+	if t.onRtnOrderModify != nil {
+		t.onRtnOrderModify(key, "", info)
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package goctp
+
+// OrderRequest 委托请求, 未赋值的字段取默认值: 登录帐号/HFTrade.HedgeFlag/限价/当日有效/任何数量/立即
+type OrderRequest struct {
+	// 帐号(交易员模式时指定)
+	InvestorID string
+	// 合约代码
+	InstrumentID string
+	// 买卖方向
+	Direction DirectionType
+	// 开平标志
+	OffsetFlag OffsetFlagType
+	// 投机套保标志
+	HedgeFlag HedgeFlagType
+	// 报单价格条件
+	PriceType OrderPriceTypeType
+	// 价格
+	LimitPrice float64
+	// 数量
+	Volume int
+	// 有效期类型
+	TimeCondition TimeConditionType
+	// GTD日期
+	GTDDate string
+	// 成交量类型
+	VolumeCondition VolumeConditionType
+	// 最小成交量
+	MinVolume int
+	// 触发条件
+	ContingentCondition ContingentConditionType
+	// 止损价
+	StopPrice float64
+	// 业务单元
+	BusinessUnit string
+	// 自动挂起标志
+	IsAutoSuspend bool
+}
+
+// NewOrderRequest 限价委托请求
+func NewOrderRequest(instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) *OrderRequest {
+	return &OrderRequest{
+		InstrumentID: instrument,
+		Direction:    buySell,
+		OffsetFlag:   openClose,
+		LimitPrice:   price,
+		Volume:       volume,
+	}
+}
+
+// SetInvestor 指定帐号
+func (r *OrderRequest) SetInvestor(investor string) *OrderRequest {
+	r.InvestorID = investor
+	return r
+}
+
+// SetHedgeFlag 投机套保标志
+func (r *OrderRequest) SetHedgeFlag(hedge HedgeFlagType) *OrderRequest {
+	r.HedgeFlag = hedge
+	return r
+}
+
+// SetPriceType 报单价格条件
+func (r *OrderRequest) SetPriceType(priceType OrderPriceTypeType) *OrderRequest {
+	r.PriceType = priceType
+	return r
+}
+
+// SetMarket 市价(任意价, 立即完成否则撤销)
+func (r *OrderRequest) SetMarket() *OrderRequest {
+	r.PriceType = OrderPriceTypeAnyPrice
+	r.TimeCondition = TimeConditionIOC
+	r.LimitPrice = 0
+	return r
+}
+
+// SetTimeCondition 有效期类型
+func (r *OrderRequest) SetTimeCondition(timeCondition TimeConditionType) *OrderRequest {
+	r.TimeCondition = timeCondition
+	return r
+}
+
+// SetGTD 指定日期(yyyyMMdd)前有效
+func (r *OrderRequest) SetGTD(date string) *OrderRequest {
+	r.TimeCondition = TimeConditionGTD
+	r.GTDDate = date
+	return r
+}
+
+// SetVolumeCondition 成交量类型
+func (r *OrderRequest) SetVolumeCondition(volumeCondition VolumeConditionType) *OrderRequest {
+	r.VolumeCondition = volumeCondition
+	return r
+}
+
+// SetMinVolume 最小成交量
+func (r *OrderRequest) SetMinVolume(volume int) *OrderRequest {
+	r.VolumeCondition = VolumeConditionMV
+	r.MinVolume = volume
+	return r
+}
+
+// SetContingent 触发条件及止损价
+func (r *OrderRequest) SetContingent(condition ContingentConditionType, stopPrice float64) *OrderRequest {
+	r.ContingentCondition = condition
+	r.StopPrice = stopPrice
+	return r
+}
+
+// SetBusinessUnit 业务单元
+func (r *OrderRequest) SetBusinessUnit(unit string) *OrderRequest {
+	r.BusinessUnit = unit
+	return r
+}
+
+// SetAutoSuspend 自动挂起
+func (r *OrderRequest) SetAutoSuspend(suspend bool) *OrderRequest {
+	r.IsAutoSuspend = suspend
+	return r
+}
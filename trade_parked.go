@@ -0,0 +1,285 @@
+package goctp
+
+import (
+	"fmt"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// ReqParkedOrderInsert 预埋单(限价), 开盘(或集合竞价)时由柜台发出. 返回 sessionID_OrderRef, 触发后即为 Orders 的 key
+func (t *HFTrade) ReqParkedOrderInsert(instrument string, buySell DirectionType, openClose OffsetFlagType, price float64, volume int) string {
+	f := ctp.CThostFtdcParkedOrderField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	if info, ok := t.Instruments.Load(instrument); ok {
+		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
+	}
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], t.InvestorID)
+	copy(f.AccountID[:], t.InvestorID)
+	f.UserType = ctp.THOST_FTDC_UT_Investor
+	f.IsAutoSuspend = ctp.TThostFtdcBoolType(0)
+	f.IsSwapOrder = ctp.TThostFtdcBoolType(0)
+	f.ForceCloseReason = ctp.THOST_FTDC_FCC_NotForceClose
+	// 参数赋值
+	id := t.getReqID()
+	copy(f.OrderRef[:], fmt.Sprintf("%012d", id))
+	copy(f.InstrumentID[:], instrument)
+	f.Direction = ctp.TThostFtdcDirectionType(buySell)
+	f.CombOffsetFlag[0] = byte(openClose)
+	f.CombHedgeFlag[0] = byte(t.hedgeFlag())
+	f.OrderPriceType = ctp.THOST_FTDC_OPT_LimitPrice
+	f.TimeCondition = ctp.THOST_FTDC_TC_GFD
+	f.VolumeCondition = ctp.THOST_FTDC_VC_AV
+	f.ContingentCondition = ctp.THOST_FTDC_CC_Immediately
+	f.LimitPrice = ctp.TThostFtdcPriceType(price)
+	f.VolumeTotalOriginal = ctp.TThostFtdcVolumeType(volume)
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:]))
+	t.ParkedOrders.Store(key, &ParkedOrderField{
+		InvestorID:          t.InvestorID,
+		InstrumentID:        instrument,
+		ExchangeID:          Bytes2String(f.ExchangeID[:]),
+		OrderRef:            Bytes2String(f.OrderRef[:]),
+		Direction:           buySell,
+		OffsetFlag:          openClose,
+		HedgeFlag:           t.hedgeFlag(),
+		LimitPrice:          price,
+		VolumeTotalOriginal: volume,
+		Status:              ParkedOrderStatusPending,
+	})
+	t.ReqParkedOrder(&f, id)
+	return key
+}
+
+// ReqParkedOrderAction 预埋撤单: 撤销委托(Orders 的 key), 开盘时由柜台发出. 返回 sessionID_OrderActionRef
+func (t *HFTrade) ReqParkedOrderAction(orderID string) string {
+	o, ok := t.Orders.Load(orderID)
+	if !ok {
+		return ""
+	}
+	var order = o.(*OrderField)
+	f := ctp.CThostFtdcParkedOrderActionField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], order.InvestorID)
+	copy(f.InstrumentID[:], order.InstrumentID)
+	copy(f.ExchangeID[:], order.ExchangeID)
+	copy(f.OrderRef[:], order.OrderRef)
+	copy(f.OrderSysID[:], order.OrderSysID)
+	f.UserType = ctp.THOST_FTDC_UT_Investor
+	f.ActionFlag = ctp.THOST_FTDC_AF_Delete
+	f.FrontID = ctp.TThostFtdcFrontIDType(order.FrontID)
+	f.SessionID = ctp.TThostFtdcSessionIDType(order.SessionID)
+	id := t.getReqID()
+	f.OrderActionRef = ctp.TThostFtdcOrderActionRefType(id)
+	key := fmt.Sprintf("%d_%012d", t.SessionID, id)
+	t.ParkedOrders.Store(key, &ParkedOrderField{
+		InvestorID:   order.InvestorID,
+		InstrumentID: order.InstrumentID,
+		ExchangeID:   order.ExchangeID,
+		OrderRef:     order.OrderRef,
+		Status:       ParkedOrderStatusPending,
+		IsAction:     true,
+		OrderID:      orderID,
+	})
+	t.ReqParkedAction(&f, id)
+	return key
+}
+
+// ReqRemoveParkedOrder 删除未触发的预埋单/预埋撤单
+func (t *HFTrade) ReqRemoveParkedOrder(parkedID string) int {
+	p, ok := t.ParkedOrders.Load(parkedID)
+	if !ok {
+		return -1
+	}
+	var parked = p.(*ParkedOrderField)
+	if parked.Status != ParkedOrderStatusNotSend { // 未被接受或已触发/删除
+		return -1
+	}
+	if parked.IsAction {
+		f := ctp.CThostFtdcRemoveParkedOrderActionField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		copy(f.InvestorID[:], parked.InvestorID)
+		copy(f.ParkedOrderActionID[:], parked.ParkedOrderID)
+		t.ReqRemoveParkedAction(&f, t.getReqID())
+	} else {
+		f := ctp.CThostFtdcRemoveParkedOrderField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		copy(f.InvestorID[:], parked.InvestorID)
+		copy(f.ParkedOrderID[:], parked.ParkedOrderID)
+		t.ReqRemoveParked(&f, t.getReqID())
+	}
+	return 0
+}
+
+// ReqQryParkedOrder 查询预埋单, 结果更新至 ParkedOrders
+func (t *HFTrade) ReqQryParkedOrder() {
+	f := ctp.CThostFtdcQryParkedOrderField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.InvestorID[:], t.InvestorID)
+	t.ReqQryParked(&f, t.getReqID())
+}
+
+// ReqQryParkedOrderAction 查询预埋撤单, 结果更新至 ParkedOrders
+func (t *HFTrade) ReqQryParkedOrderAction() {
+	f := ctp.CThostFtdcQryParkedOrderActionField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.InvestorID[:], t.InvestorID)
+	t.ReqQryParkedAction(&f, t.getReqID())
+}
+
+// RegOnRtnParkedOrder 注册预埋单响应(被接受/已触发/已删除)
+func (t *HFTrade) RegOnRtnParkedOrder(on OnRtnParkedOrderType) {
+	t.onRtnParkedOrder = on
+}
+
+// RegOnErrRtnParkedOrder 注册预埋单错误响应
+func (t *HFTrade) RegOnErrRtnParkedOrder(on OnRtnErrParkedOrderType) {
+	t.onErrRtnParkedOrder = on
+}
+
+// RspParkedOrderInsert 预埋单响应
+func (t *HFTrade) RspParkedOrderInsert(field *ctp.CThostFtdcParkedOrderField, info *ctp.CThostFtdcRspInfoField) {
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.OrderRef[:]))
+	t.rspParked(key, parkedOrderField(field), info)
+}
+
+// RspParkedOrderAction 预埋撤单响应
+func (t *HFTrade) RspParkedOrderAction(field *ctp.CThostFtdcParkedOrderActionField, info *ctp.CThostFtdcRspInfoField) {
+	key := fmt.Sprintf("%d_%012d", t.SessionID, field.OrderActionRef)
+	t.rspParked(key, parkedActionField(field), info)
+}
+
+// RspRemoveParkedOrder 删除预埋单响应
+func (t *HFTrade) RspRemoveParkedOrder(field *ctp.CThostFtdcRemoveParkedOrderField, info *ctp.CThostFtdcRspInfoField) {
+	t.removeParked(Bytes2String(field.ParkedOrderID[:]), false, info)
+}
+
+// RspRemoveParkedOrderAction 删除预埋撤单响应
+func (t *HFTrade) RspRemoveParkedOrderAction(field *ctp.CThostFtdcRemoveParkedOrderActionField, info *ctp.CThostFtdcRspInfoField) {
+	t.removeParked(Bytes2String(field.ParkedOrderActionID[:]), true, info)
+}
+
+// RspQryParkedOrder 查预埋单响应
+func (t *HFTrade) RspQryParkedOrder(field *ctp.CThostFtdcParkedOrderField) {
+	t.qryParked(parkedOrderField(field))
+}
+
+// RspQryParkedOrderAction 查预埋撤单响应
+func (t *HFTrade) RspQryParkedOrderAction(field *ctp.CThostFtdcParkedOrderActionField) {
+	t.qryParked(parkedActionField(field))
+}
+
+func (t *HFTrade) rspParked(key string, rsp *ParkedOrderField, info *ctp.CThostFtdcRspInfoField) {
+	p, _ := t.ParkedOrders.LoadOrStore(key, rsp)
+	var f = p.(*ParkedOrderField)
+	f.ParkedOrderID = rsp.ParkedOrderID
+	if info != nil && info.ErrorID != 0 {
+		f.Status = ParkedOrderStatusRejected
+		f.ErrorID = int(info.ErrorID)
+		f.ErrorMsg = Bytes2String(info.ErrorMsg[:])
+		if t.onErrRtnParkedOrder != nil {
+			t.onErrRtnParkedOrder(f, &RspInfoField{ErrorID: f.ErrorID, ErrorMsg: f.ErrorMsg})
+		}
+		return
+	}
+	if f.Status < rsp.Status { // 响应可能晚于触发
+		f.Status = rsp.Status
+	}
+	if t.onRtnParkedOrder != nil {
+		t.onRtnParkedOrder(f)
+	}
+}
+
+func (t *HFTrade) removeParked(parkedOrderID string, isAction bool, info *ctp.CThostFtdcRspInfoField) {
+	t.ParkedOrders.Range(func(key, value interface{}) bool {
+		var f = value.(*ParkedOrderField)
+		if f.ParkedOrderID != parkedOrderID || f.IsAction != isAction {
+			return true
+		}
+		if info != nil && info.ErrorID != 0 {
+			if t.onErrRtnParkedOrder != nil {
+				t.onErrRtnParkedOrder(f, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+			}
+		} else {
+			f.Status = ParkedOrderStatusDeleted
+			if t.onRtnParkedOrder != nil {
+				t.onRtnParkedOrder(f)
+			}
+		}
+		return false
+	})
+}
+
+// qryParked 查询结果: 按预埋编号更新已有记录, 其他会话的以预埋编号为 key 添加
+func (t *HFTrade) qryParked(rsp *ParkedOrderField) {
+	if len(rsp.ParkedOrderID) == 0 { // 查询结果为空
+		return
+	}
+	var f *ParkedOrderField
+	t.ParkedOrders.Range(func(key, value interface{}) bool {
+		if p := value.(*ParkedOrderField); p.ParkedOrderID == rsp.ParkedOrderID && p.IsAction == rsp.IsAction {
+			f = p
+			return false
+		}
+		return true
+	})
+	if f == nil {
+		t.ParkedOrders.Store(rsp.ParkedOrderID, rsp)
+		return
+	}
+	if f.Status != rsp.Status {
+		f.Status, f.ErrorID, f.ErrorMsg = rsp.Status, rsp.ErrorID, rsp.ErrorMsg
+		if t.IsLogin && t.onRtnParkedOrder != nil {
+			t.onRtnParkedOrder(f)
+		}
+	}
+}
+
+// parkedSend 预埋单触发: 由 RtnOrder 调用, 按报单引用匹配未发送的预埋单
+func (t *HFTrade) parkedSend(orderID string, order *OrderField) {
+	t.ParkedOrders.Range(func(key, value interface{}) bool {
+		var f = value.(*ParkedOrderField)
+		if f.IsAction || f.Status != ParkedOrderStatusNotSend || f.OrderRef != order.OrderRef || f.InstrumentID != order.InstrumentID || f.InvestorID != order.InvestorID {
+			return true
+		}
+		f.Status = ParkedOrderStatusSend
+		f.OrderID = orderID
+		if t.IsLogin && t.onRtnParkedOrder != nil {
+			t.onRtnParkedOrder(f)
+		}
+		return false
+	})
+}
+
+func parkedOrderField(field *ctp.CThostFtdcParkedOrderField) *ParkedOrderField {
+	return &ParkedOrderField{
+		InvestorID:          Bytes2String(field.InvestorID[:]),
+		InstrumentID:        Bytes2String(field.InstrumentID[:]),
+		ExchangeID:          Bytes2String(field.ExchangeID[:]),
+		ParkedOrderID:       Bytes2String(field.ParkedOrderID[:]),
+		OrderRef:            Bytes2String(field.OrderRef[:]),
+		Direction:           DirectionType(field.Direction),
+		OffsetFlag:          OffsetFlagType(field.CombOffsetFlag[0]),
+		HedgeFlag:           HedgeFlagType(field.CombHedgeFlag[0]),
+		LimitPrice:          float64(field.LimitPrice),
+		VolumeTotalOriginal: int(field.VolumeTotalOriginal),
+		Status:              ParkedOrderStatusType(field.Status),
+		ErrorID:             int(field.ErrorID),
+		ErrorMsg:            Bytes2String(field.ErrorMsg[:]),
+	}
+}
+
+func parkedActionField(field *ctp.CThostFtdcParkedOrderActionField) *ParkedOrderField {
+	return &ParkedOrderField{
+		InvestorID:    Bytes2String(field.InvestorID[:]),
+		InstrumentID:  Bytes2String(field.InstrumentID[:]),
+		ExchangeID:    Bytes2String(field.ExchangeID[:]),
+		ParkedOrderID: Bytes2String(field.ParkedOrderActionID[:]),
+		OrderRef:      Bytes2String(field.OrderRef[:]),
+		Status:        ParkedOrderStatusType(field.Status),
+		IsAction:      true,
+		OrderID:       fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.OrderRef[:])),
+		ErrorID:       int(field.ErrorID),
+		ErrorMsg:      Bytes2String(field.ErrorMsg[:]),
+	}
+}
@@ -0,0 +1,124 @@
+package goctp
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+const (
+	qryInterval = 1100 * time.Millisecond // 查询间隔: CTP 查询限流为每秒 1 次
+	qryTimeout  = 5 * time.Second         // 查询无响应时放弃等待
+)
+
+// qry 查询请求排队: 前一查询完成(或超时)并间隔 qryInterval 后发出. 队列未启动(登录前/Release 后)时返回错误
+func (t *HFTrade) qry(send func()) error {
+	t.qryMu.Lock()
+	defer t.qryMu.Unlock()
+	if t.qryStop == nil {
+		return fmt.Errorf("[%d] 查询队列未启动(未登录或已 Release)", -1)
+	}
+	t.qryItems = append(t.qryItems, send)
+	select {
+	case t.qrySignal <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// qryFinished 查询响应完成(RspQry* 的最后一条)
+func (t *HFTrade) qryFinished() {
+	t.qryMu.Lock()
+	chDone := t.qryDone
+	t.qryMu.Unlock()
+	select {
+	case chDone <- struct{}{}:
+	default:
+	}
+}
+
+// qryStart 启动查询队列(登录成功时), 已启动时不处理
+func (t *HFTrade) qryStart() {
+	t.qryMu.Lock()
+	defer t.qryMu.Unlock()
+	if t.qryStop != nil {
+		return
+	}
+	t.qryItems = nil
+	t.qrySignal = make(chan struct{}, 1)
+	t.qryDone = make(chan struct{}, 1)
+	t.qryStop = make(chan struct{})
+	t.qryWait.Add(1)
+	go t.qryRun(t.qrySignal, t.qryDone, t.qryStop)
+}
+
+// qryEnd 停止查询队列, 等待已发出的查询响应完成, 未发出的查询丢弃
+func (t *HFTrade) qryEnd() {
+	t.qryMu.Lock()
+	if t.qryStop != nil {
+		close(t.qryStop)
+		t.qryStop = nil
+	}
+	t.qryItems = nil
+	t.qryMu.Unlock()
+	t.qryWait.Wait()
+}
+
+func (t *HFTrade) qryRun(chSignal, chDone, chStop chan struct{}) {
+	defer t.qryWait.Done()
+	var last time.Time // 上一查询完成的时间
+	for {
+		t.qryMu.Lock()
+		if len(t.qryItems) == 0 {
+			t.qryMu.Unlock()
+			select {
+			case <-chSignal:
+				continue
+			case <-chStop:
+				return
+			}
+		}
+		send := t.qryItems[0]
+		t.qryItems = t.qryItems[1:]
+		t.qryMu.Unlock()
+		if wait := qryInterval - time.Since(last); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-chStop:
+				return
+			}
+		}
+		select { // 清除超时查询的迟到响应
+		case <-chDone:
+		default:
+		}
+		send()
+		select { // 已发出的查询等待响应, Release 时亦等待
+		case <-chDone:
+		case <-time.After(qryTimeout):
+			fmt.Println(time.Now().Local().Format("2006-01-02 15:04:05"), " qry timeout")
+		}
+		last = time.Now()
+	}
+}
+
+// qryAccount 查询资金(排队)
+func (t *HFTrade) qryAccount() {
+	t.qry(func() {
+		f := ctp.CThostFtdcQryTradingAccountField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		t.ReqQryTradingAccount(&f, t.getReqID())
+	})
+}
+
+// qryPosition 查询持仓(排队)
+func (t *HFTrade) qryPosition() {
+	t.qry(func() {
+		f := ctp.CThostFtdcQryInvestorPositionField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		atomic.AddInt64(&t.posiQry, 1)
+		t.ReqQryInvestorPosition(&f, t.getReqID())
+	})
+}
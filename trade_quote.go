@@ -0,0 +1,206 @@
+package goctp
+
+import (
+	"fmt"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// ReqQuoteInsert 双边报价, 回应询价时传入 forQuoteSysID(否则为空). 返回 sessionID_QuoteRef
+// 买卖两边由交易所衍生为普通委托, 其 OrderRef 在此生成, 可通过 QuoteField.BidOrderID/AskOrderID 在 Orders 中查找
+func (t *HFTrade) ReqQuoteInsert(instrument string, bidPrice float64, bidVolume int, bidOffset OffsetFlagType, askPrice float64, askVolume int, askOffset OffsetFlagType, forQuoteSysID string) string {
+	f := ctp.CThostFtdcInputQuoteField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	if info, ok := t.Instruments.Load(instrument); ok {
+		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
+	}
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], t.InvestorID)
+	// 参数赋值
+	id := t.getReqID()
+	copy(f.QuoteRef[:], fmt.Sprintf("%012d", id))
+	copy(f.BidOrderRef[:], fmt.Sprintf("%012d", t.getReqID()))
+	copy(f.AskOrderRef[:], fmt.Sprintf("%012d", t.getReqID()))
+	copy(f.InstrumentID[:], instrument)
+	copy(f.ForQuoteSysID[:], forQuoteSysID)
+	f.RequestID = ctp.TThostFtdcRequestIDType(id)
+	f.BidPrice = ctp.TThostFtdcPriceType(bidPrice)
+	f.BidVolume = ctp.TThostFtdcVolumeType(bidVolume)
+	f.BidOffsetFlag = ctp.TThostFtdcOffsetFlagType(bidOffset)
+	f.BidHedgeFlag = ctp.TThostFtdcHedgeFlagType(t.hedgeFlag())
+	f.AskPrice = ctp.TThostFtdcPriceType(askPrice)
+	f.AskVolume = ctp.TThostFtdcVolumeType(askVolume)
+	f.AskOffsetFlag = ctp.TThostFtdcOffsetFlagType(askOffset)
+	f.AskHedgeFlag = ctp.TThostFtdcHedgeFlagType(t.hedgeFlag())
+	t.ReqQuote(&f, id)
+	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.QuoteRef[:]))
+}
+
+// ReqQuoteAction 撤销报价(两边同时撤销)
+func (t *HFTrade) ReqQuoteAction(quoteID string) int {
+	if o, ok := t.Quotes.Load(quoteID); ok {
+		var quote = o.(*QuoteField)
+		f := ctp.CThostFtdcInputQuoteActionField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		copy(f.UserID[:], t.UserID)
+		copy(f.InvestorID[:], quote.InvestorID)
+		copy(f.InstrumentID[:], quote.InstrumentID)
+		copy(f.ExchangeID[:], quote.ExchangeID)
+		copy(f.QuoteRef[:], quote.QuoteRef)
+		f.ActionFlag = ctp.THOST_FTDC_AF_Delete
+		f.FrontID = ctp.TThostFtdcFrontIDType(quote.FrontID)
+		f.SessionID = ctp.TThostFtdcSessionIDType(quote.SessionID)
+		t.ReqActionQuote(&f, t.getReqID())
+		return 0
+	}
+	return -1
+}
+
+// ReqForQuoteInsert 询价
+func (t *HFTrade) ReqForQuoteInsert(instrument string) {
+	f := ctp.CThostFtdcInputForQuoteField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	if info, ok := t.Instruments.Load(instrument); ok {
+		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
+	}
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], t.InvestorID)
+	id := t.getReqID()
+	copy(f.ForQuoteRef[:], fmt.Sprintf("%012d", id))
+	copy(f.InstrumentID[:], instrument)
+	t.ReqForQuote(&f, id)
+}
+
+// RegOnRtnQuote 注册报价响应(提交及状态变化)
+func (t *HFTrade) RegOnRtnQuote(on OnRtnQuoteType) {
+	t.onRtnQuote = on
+}
+
+// RegOnErrRtnQuote 注册报价错误响应
+func (t *HFTrade) RegOnErrRtnQuote(on OnRtnErrQuoteType) {
+	t.onErrRtnQuote = on
+}
+
+// RegOnErrQuoteAction 注册撤销报价错误响应
+func (t *HFTrade) RegOnErrQuoteAction(on OnRtnErrActionType) {
+	t.onErrQuoteAction = on
+}
+
+// RegOnErrRtnForQuote 注册询价错误响应
+func (t *HFTrade) RegOnErrRtnForQuote(on OnRtnErrForQuoteType) {
+	t.onErrRtnForQuote = on
+}
+
+// RegOnRtnForQuote 注册询价通知(交易前置推送, 行情端见 HFQuote.SubscribeForQuoteRsp)
+func (t *HFTrade) RegOnRtnForQuote(on OnRtnForQuoteType) {
+	t.onRtnForQuote = on
+}
+
+// RtnQuote 报价响应
+func (t *HFTrade) RtnQuote(field *ctp.CThostFtdcQuoteField) {
+	if _, exists := t.Investors[Bytes2String(field.InvestorID[:])]; !exists {
+		return
+	}
+	key := fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.QuoteRef[:]))
+	of, _ := t.Quotes.LoadOrStore(key, &QuoteField{
+		InvestorID:    Bytes2String(field.InvestorID[:]),
+		InstrumentID:  Bytes2String(field.InstrumentID[:]),
+		QuoteRef:      Bytes2String(field.QuoteRef[:]),
+		ExchangeID:    Bytes2String(field.ExchangeID[:]),
+		ForQuoteSysID: Bytes2String(field.ForQuoteSysID[:]),
+		BidPrice:      float64(field.BidPrice),
+		BidVolume:     int(field.BidVolume),
+		BidOffsetFlag: OffsetFlagType(field.BidOffsetFlag),
+		AskPrice:      float64(field.AskPrice),
+		AskVolume:     int(field.AskVolume),
+		AskOffsetFlag: OffsetFlagType(field.AskOffsetFlag),
+		HedgeFlag:     HedgeFlagType(field.BidHedgeFlag),
+		BidOrderID:    fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.BidOrderRef[:])),
+		AskOrderID:    fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.AskOrderRef[:])),
+		InsertDate:    Bytes2String(field.InsertDate[:]),
+		InsertTime:    Bytes2String(field.InsertTime[:]),
+		FrontID:       int(field.FrontID),
+		SessionID:     int(field.SessionID),
+		IsLocal:       int(field.SessionID) == t.SessionID,
+	})
+	var f = of.(*QuoteField)
+	f.QuoteStatus = OrderStatusType(field.QuoteStatus)
+	f.StatusMsg = Bytes2String(field.StatusMsg[:])
+	f.CancelTime = Bytes2String(field.CancelTime[:])
+	if sysID := Bytes2String(field.QuoteSysID[:]); len(sysID) > 0 {
+		f.QuoteSysID = sysID
+	}
+	if !t.IsLogin { // 登录前不响应
+		return
+	}
+	if field.OrderSubmitStatus == ctp.THOST_FTDC_OSS_InsertRejected { // 交易所拒绝
+		f.QuoteStatus = OrderStatusCanceled
+		if t.onErrRtnQuote != nil {
+			t.onErrRtnQuote(f, &RspInfoField{
+				ErrorID:  -1,
+				ErrorMsg: f.StatusMsg,
+			})
+		}
+	} else if t.onRtnQuote != nil {
+		t.onRtnQuote(f)
+	}
+}
+
+// ErrRtnQuoteInsert 报价错误
+func (t *HFTrade) ErrRtnQuoteInsert(field *ctp.CThostFtdcInputQuoteField, info *ctp.CThostFtdcRspInfoField) {
+	if !t.IsLogin { // 过滤当日以前登录时的错误
+		return
+	}
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.QuoteRef[:]))
+	of, _ := t.Quotes.LoadOrStore(key, &QuoteField{
+		InvestorID:    Bytes2String(field.InvestorID[:]),
+		InstrumentID:  Bytes2String(field.InstrumentID[:]),
+		QuoteRef:      Bytes2String(field.QuoteRef[:]),
+		ExchangeID:    Bytes2String(field.ExchangeID[:]),
+		ForQuoteSysID: Bytes2String(field.ForQuoteSysID[:]),
+		BidPrice:      float64(field.BidPrice),
+		BidVolume:     int(field.BidVolume),
+		BidOffsetFlag: OffsetFlagType(field.BidOffsetFlag),
+		AskPrice:      float64(field.AskPrice),
+		AskVolume:     int(field.AskVolume),
+		AskOffsetFlag: OffsetFlagType(field.AskOffsetFlag),
+		HedgeFlag:     HedgeFlagType(field.BidHedgeFlag),
+		BidOrderID:    fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.BidOrderRef[:])),
+		AskOrderID:    fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.AskOrderRef[:])),
+		SessionID:     t.SessionID,
+		IsLocal:       true,
+	})
+	var f = of.(*QuoteField)
+	f.QuoteStatus = OrderStatusCanceled
+	f.StatusMsg = Bytes2String(info.ErrorMsg[:])
+	if t.onErrRtnQuote != nil {
+		t.onErrRtnQuote(f, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+	}
+}
+
+// ErrRtnQuoteAction 撤销报价错误
+func (t *HFTrade) ErrRtnQuoteAction(field *ctp.CThostFtdcQuoteActionField, info *ctp.CThostFtdcRspInfoField) {
+	if t.IsLogin && t.onErrQuoteAction != nil {
+		t.onErrQuoteAction(fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.QuoteRef[:])), &RspInfoField{
+			ErrorID:  int(info.ErrorID),
+			ErrorMsg: Bytes2String(info.ErrorMsg[:]),
+		})
+	}
+}
+
+// ErrRtnForQuoteInsert 询价错误
+func (t *HFTrade) ErrRtnForQuoteInsert(field *ctp.CThostFtdcInputForQuoteField, info *ctp.CThostFtdcRspInfoField) {
+	if t.IsLogin && t.onErrRtnForQuote != nil {
+		t.onErrRtnForQuote(Bytes2String(field.InstrumentID[:]), &RspInfoField{
+			ErrorID:  int(info.ErrorID),
+			ErrorMsg: Bytes2String(info.ErrorMsg[:]),
+		})
+	}
+}
+
+// RtnForQuoteRsp 询价通知
+func (t *HFTrade) RtnForQuoteRsp(field *ctp.CThostFtdcForQuoteRspField) {
+	if t.IsLogin && t.onRtnForQuote != nil {
+		t.onRtnForQuote(newForQuoteField(field))
+	}
+}
@@ -0,0 +1,234 @@
+package goctp
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// RiskRuleType 委托前风控规则, 返回 nil 为通过, 否则委托被拒绝(经 RegOnErrRtnOrder 响应)
+// req 中未赋值的帐号/投保标志/价格条件已取默认值
+type RiskRuleType = func(req *OrderRequest) *RspInfoField
+
+// AddRiskRule 添加委托前风控规则(ReqOrderInsert* 均经过), 按添加顺序检查
+func (t *HFTrade) AddRiskRule(rules ...RiskRuleType) {
+	t.riskRules = append(t.riskRules, rules...)
+}
+
+// UpdateTick 更新合约最新行情(风控用)并驱动本地条件单(未使用 AttachQuote 时在 RegOnTick 中调用)
+func (t *HFTrade) UpdateTick(tick *TickField) {
+	t.ticks.Store(tick.InstrumentID, tick)
+	t.UpdateCondOrders(tick)
+}
+
+// RiskMaxOrderVolume 风控: 数量不超过合约的限价/市价单最大下单量
+func (t *HFTrade) RiskMaxOrderVolume() RiskRuleType {
+	return func(req *OrderRequest) *RspInfoField {
+		inst, ok := t.Instruments.Load(req.InstrumentID)
+		if !ok {
+			return nil
+		}
+		max := inst.(*InstrumentField).MaxLimitOrderVolume
+		if req.PriceType != OrderPriceTypeLimitPrice {
+			max = inst.(*InstrumentField).MaxMarketOrderVolume
+		}
+		if max > 0 && req.Volume > max {
+			return riskInfo("数量 %d 超过最大下单量 %d", req.Volume, max)
+		}
+		return nil
+	}
+}
+
+// RiskPriceLimit 风控: 限价在最新行情的涨跌停板内
+func (t *HFTrade) RiskPriceLimit() RiskRuleType {
+	return func(req *OrderRequest) *RspInfoField {
+		tick, ok := t.ticks.Load(req.InstrumentID)
+		if !ok || req.PriceType != OrderPriceTypeLimitPrice {
+			return nil
+		}
+		upper, lower := tick.(*TickField).UpperLimitPrice, tick.(*TickField).LowerLimitPrice
+		if ValidPrice(upper) && req.LimitPrice > upper+1e-8 || ValidPrice(lower) && req.LimitPrice < lower-1e-8 {
+			return riskInfo("价格 %g 超出涨跌停板 [%g, %g]", req.LimitPrice, lower, upper)
+		}
+		return nil
+	}
+}
+
+// RiskPriceTick 风控: 限价为最小变动价位的整数倍
+func (t *HFTrade) RiskPriceTick() RiskRuleType {
+	return func(req *OrderRequest) *RspInfoField {
+		inst, ok := t.Instruments.Load(req.InstrumentID)
+		if !ok || req.PriceType != OrderPriceTypeLimitPrice {
+			return nil
+		}
+		tick := inst.(*InstrumentField).PriceTick
+		if n := req.LimitPrice / tick; tick > 0 && math.Abs(n-math.Round(n)) > 1e-6 {
+			return riskInfo("价格 %g 不是最小变动价位 %g 的整数倍", req.LimitPrice, tick)
+		}
+		return nil
+	}
+}
+
+// RiskMaxPosition 风控: 开仓时 同方向持仓+开仓挂单+委托数量 不超过 max
+func (t *HFTrade) RiskMaxPosition(max int) RiskRuleType {
+	return func(req *OrderRequest) *RspInfoField {
+		if req.OffsetFlag != OffsetFlagOpen {
+			return nil
+		}
+		var posiDire = PosiDirectionLong
+		if req.Direction == DirectionSell {
+			posiDire = PosiDirectionShort
+		}
+		var position, working int
+		if p, ok := t.positions(req.InvestorID).Load(PositionKey(req.InstrumentID, posiDire, req.HedgeFlag)); ok {
+			position = p.(*PositionField).Position
+		}
+		for _, o := range t.WorkingOrders(FilterInvestor(req.InvestorID), FilterInstrument(req.InstrumentID), FilterDirection(req.Direction)) {
+			if o.OffsetFlag == OffsetFlagOpen && o.HedgeFlag == req.HedgeFlag {
+				working += o.VolumeLeft
+			}
+		}
+		if position+working+req.Volume > max {
+			return riskInfo("持仓 %d 挂单 %d 委托 %d 超过最大持仓 %d", position, working, req.Volume, max)
+		}
+		return nil
+	}
+}
+
+// RiskMargin 风控: 开仓所需保证金(价格*数量*合约乘数*marginRatio)不超过帐户可用资金, 市价以涨跌停价计算.
+// 无行情时以限价或持仓的昨结算价计算, 均无时拒绝
+func (t *HFTrade) RiskMargin(marginRatio float64) RiskRuleType {
+	return func(req *OrderRequest) *RspInfoField {
+		inst, ok := t.Instruments.Load(req.InstrumentID)
+		if !ok || req.OffsetFlag != OffsetFlagOpen {
+			return nil
+		}
+		price := req.LimitPrice
+		if tick, ok := t.ticks.Load(req.InstrumentID); ok && req.PriceType != OrderPriceTypeLimitPrice {
+			if price = tick.(*TickField).LastPrice; req.Direction == DirectionBuy && ValidPrice(tick.(*TickField).UpperLimitPrice) {
+				price = tick.(*TickField).UpperLimitPrice
+			}
+		}
+		if !ValidPrice(price) {
+			price = req.LimitPrice
+		}
+		if !ValidPrice(price) {
+			t.positions(req.InvestorID).Range(func(_, value interface{}) bool {
+				if p := value.(*PositionField); p.InstrumentID == req.InstrumentID && ValidPrice(p.PreSettlementPrice) {
+					price = p.PreSettlementPrice
+					return false
+				}
+				return true
+			})
+		}
+		if !ValidPrice(price) {
+			return riskInfo("%s 无行情, 无法计算保证金", req.InstrumentID)
+		}
+		account := t.Account
+		if acc, ok := t.UserAccounts[req.InvestorID]; ok {
+			account = acc
+		}
+		margin := price * float64(req.Volume) * float64(inst.(*InstrumentField).VolumeMultiple) * marginRatio
+		if margin > account.Available {
+			return riskInfo("所需保证金 %.2f 超过可用资金 %.2f", margin, account.Available)
+		}
+		return nil
+	}
+}
+
+// RiskOrderRate 风控: 每秒委托不超过 perSecond 笔(含被之后的规则拒绝的委托, 宜最后添加)
+func (t *HFTrade) RiskOrderRate(perSecond int) RiskRuleType {
+	var mu sync.Mutex
+	var times []time.Time
+	return func(req *OrderRequest) *RspInfoField {
+		mu.Lock()
+		defer mu.Unlock()
+		now := time.Now()
+		for len(times) > 0 && now.Sub(times[0]) >= time.Second {
+			times = times[1:]
+		}
+		if len(times) >= perSecond {
+			return riskInfo("每秒委托超过 %d 笔", perSecond)
+		}
+		times = append(times, now)
+		return nil
+	}
+}
+
+// riskCheck 依次检查风控规则, 返回第一个拒绝原因
+func (t *HFTrade) riskCheck(req *OrderRequest) *RspInfoField {
+	if len(t.riskRules) == 0 {
+		return nil
+	}
+	r := *req
+	if len(r.InvestorID) == 0 {
+		r.InvestorID = t.InvestorID
+	}
+	if r.HedgeFlag == 0 {
+		r.HedgeFlag = t.hedgeFlag()
+	}
+	if r.PriceType == 0 {
+		r.PriceType = OrderPriceTypeLimitPrice
+	}
+	for _, rule := range t.riskRules {
+		if info := rule(&r); info != nil {
+			return info
+		}
+	}
+	return nil
+}
+
+// rejectOrder 委托被拒绝(交易所/本地风控): 保存为已撤单并响应 RegOnErrRtnOrder
+func (t *HFTrade) rejectOrder(field *ctp.CThostFtdcInputOrderField, info *RspInfoField) {
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.OrderRef[:]))
+	of, loaded := t.Orders.LoadOrStore(key, &OrderField{
+		InvestorID:          Bytes2String(field.InvestorID[:]),
+		InstrumentID:        Bytes2String(field.InstrumentID[:]),
+		SessionID:           t.SessionID,
+		FrontID:             0,
+		OrderRef:            Bytes2String(field.OrderRef[:]),
+		Direction:           DirectionType(field.Direction),
+		OffsetFlag:          OffsetFlagType(field.CombOffsetFlag[0]),
+		HedgeFlag:           HedgeFlagType(field.CombHedgeFlag[0]),
+		PriceType:           OrderPriceTypeType(field.OrderPriceType),
+		LimitPrice:          float64(field.LimitPrice),
+		TimeCondition:       TimeConditionType(field.TimeCondition),
+		VolumeCondition:     VolumeConditionType(field.VolumeCondition),
+		MinVolume:           int(field.MinVolume),
+		VolumeTotalOriginal: int(field.VolumeTotalOriginal),
+		VolumeLeft:          int(field.VolumeTotalOriginal),
+		ExchangeID:          Bytes2String(field.ExchangeID[:]),
+		OrderSubmitStatus:   OrderSubmitStatusInsertRejected,
+		IsLocal:             true,
+	})
+	t.sentOrders.Delete(key)
+	var o = of.(*OrderField)
+	if loaded && o.OrderStatus == OrderStatusCanceled { // RspOrderInsert 与 ErrRtnOrderInsert 均有响应
+		return
+	}
+	t.statActionDone(key) // 确认前已发出的撤单(本地/CTP 拒绝不计入交易所错单)
+	o.OrderStatus = OrderStatusCanceled
+	o.OrderSubmitStatus = OrderSubmitStatusInsertRejected
+	o.StatusMsg = info.ErrorMsg
+	if t.onErrRtnOrder != nil {
+		t.onErrRtnOrder(o, info)
+	}
+	t.notify(key, info)
+}
+
+// positions 帐号的持仓(交易员模式时为 UserPositions 中对应帐号)
+func (t *HFTrade) positions(investor string) *sync.Map {
+	if len(investor) > 0 && investor != t.InvestorID {
+		if ps, ok := t.UserPositions[investor]; ok {
+			return ps
+		}
+	}
+	return &t.Positions
+}
+
+func riskInfo(format string, a ...interface{}) *RspInfoField {
+	return &RspInfoField{ErrorID: -1, ErrorMsg: "风控: " + fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,157 @@
+package goctp
+
+import (
+	"fmt"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// ReqOptionSelfCloseInsert 期权自对冲申请(如卖方持仓到期不自对冲: OptSelfCloseFlagReserveOptionPosition). 返回 sessionID_OptionSelfCloseRef
+func (t *HFTrade) ReqOptionSelfCloseInsert(instrument string, volume int, flag OptSelfCloseFlagType) string {
+	f := ctp.CThostFtdcInputOptionSelfCloseField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	if info, ok := t.Instruments.Load(instrument); ok {
+		copy(f.ExchangeID[:], info.(*InstrumentField).ExchangeID)
+	}
+	copy(f.UserID[:], t.UserID)
+	copy(f.InvestorID[:], t.InvestorID)
+	copy(f.AccountID[:], t.InvestorID)
+	// 参数赋值
+	id := t.getReqID()
+	copy(f.OptionSelfCloseRef[:], fmt.Sprintf("%012d", id))
+	copy(f.InstrumentID[:], instrument)
+	f.RequestID = ctp.TThostFtdcRequestIDType(id)
+	f.Volume = ctp.TThostFtdcVolumeType(volume)
+	f.HedgeFlag = ctp.TThostFtdcHedgeFlagType(t.hedgeFlag())
+	f.OptSelfCloseFlag = ctp.TThostFtdcOptSelfCloseFlagType(flag)
+	t.ReqOptionSelfClose(&f, id)
+	return fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OptionSelfCloseRef[:]))
+}
+
+// ReqOptionSelfCloseAction 撤销期权自对冲申请
+func (t *HFTrade) ReqOptionSelfCloseAction(selfCloseID string) int {
+	if o, ok := t.OptionSelfCloses.Load(selfCloseID); ok {
+		var sc = o.(*OptionSelfCloseField)
+		f := ctp.CThostFtdcInputOptionSelfCloseActionField{}
+		copy(f.BrokerID[:], t.BrokerID)
+		copy(f.UserID[:], t.UserID)
+		copy(f.InvestorID[:], sc.InvestorID)
+		copy(f.InstrumentID[:], sc.InstrumentID)
+		copy(f.ExchangeID[:], sc.ExchangeID)
+		copy(f.OptionSelfCloseRef[:], sc.OptionSelfCloseRef)
+		f.ActionFlag = ctp.THOST_FTDC_AF_Delete
+		f.FrontID = ctp.TThostFtdcFrontIDType(sc.FrontID)
+		f.SessionID = ctp.TThostFtdcSessionIDType(sc.SessionID)
+		t.ReqActionOptionSelfClose(&f, t.getReqID())
+		return 0
+	}
+	return -1
+}
+
+// ReqQryOptionSelfClose 查询期权自对冲, 结果更新至 OptionSelfCloses
+func (t *HFTrade) ReqQryOptionSelfClose() {
+	f := ctp.CThostFtdcQryOptionSelfCloseField{}
+	copy(f.BrokerID[:], t.BrokerID)
+	copy(f.InvestorID[:], t.InvestorID)
+	t.ReqQrySelfClose(&f, t.getReqID())
+}
+
+// RegOnRtnOptionSelfClose 注册期权自对冲响应(提交及状态变化)
+func (t *HFTrade) RegOnRtnOptionSelfClose(on OnRtnOptionSelfCloseType) {
+	t.onRtnSelfClose = on
+}
+
+// RegOnErrRtnOptionSelfClose 注册期权自对冲错误响应
+func (t *HFTrade) RegOnErrRtnOptionSelfClose(on OnRtnErrOptionSelfCloseType) {
+	t.onErrRtnSelfClose = on
+}
+
+// RegOnErrOptionSelfCloseAction 注册撤销期权自对冲错误响应
+func (t *HFTrade) RegOnErrOptionSelfCloseAction(on OnRtnErrActionType) {
+	t.onErrSelfCloseAction = on
+}
+
+// RtnOptionSelfClose 期权自对冲响应
+func (t *HFTrade) RtnOptionSelfClose(field *ctp.CThostFtdcOptionSelfCloseField) {
+	if _, exists := t.Investors[Bytes2String(field.InvestorID[:])]; !exists {
+		return
+	}
+	key := fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.OptionSelfCloseRef[:]))
+	of, _ := t.OptionSelfCloses.LoadOrStore(key, &OptionSelfCloseField{
+		InvestorID:         Bytes2String(field.InvestorID[:]),
+		InstrumentID:       Bytes2String(field.InstrumentID[:]),
+		OptionSelfCloseRef: Bytes2String(field.OptionSelfCloseRef[:]),
+		ExchangeID:         Bytes2String(field.ExchangeID[:]),
+		Volume:             int(field.Volume),
+		HedgeFlag:          HedgeFlagType(field.HedgeFlag),
+		OptSelfCloseFlag:   OptSelfCloseFlagType(field.OptSelfCloseFlag),
+		InsertDate:         Bytes2String(field.InsertDate[:]),
+		InsertTime:         Bytes2String(field.InsertTime[:]),
+		FrontID:            int(field.FrontID),
+		SessionID:          int(field.SessionID),
+		IsLocal:            int(field.SessionID) == t.SessionID,
+	})
+	var f = of.(*OptionSelfCloseField)
+	f.ExecResult = ExecResultType(field.ExecResult)
+	f.StatusMsg = Bytes2String(field.StatusMsg[:])
+	f.CancelTime = Bytes2String(field.CancelTime[:])
+	if sysID := Bytes2String(field.OptionSelfCloseSysID[:]); len(sysID) > 0 {
+		f.OptionSelfCloseSysID = sysID
+	}
+	if !t.IsLogin { // 登录前不响应
+		return
+	}
+	if field.OrderSubmitStatus == ctp.THOST_FTDC_OSS_InsertRejected { // 交易所拒绝
+		f.ExecResult = ExecResultCanceled
+		if t.onErrRtnSelfClose != nil {
+			t.onErrRtnSelfClose(f, &RspInfoField{
+				ErrorID:  -1,
+				ErrorMsg: f.StatusMsg,
+			})
+		}
+	} else if t.onRtnSelfClose != nil {
+		t.onRtnSelfClose(f)
+	}
+}
+
+// ErrRtnOptionSelfCloseInsert 期权自对冲错误
+func (t *HFTrade) ErrRtnOptionSelfCloseInsert(field *ctp.CThostFtdcInputOptionSelfCloseField, info *ctp.CThostFtdcRspInfoField) {
+	if !t.IsLogin { // 过滤当日以前登录时的错误
+		return
+	}
+	key := fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(field.OptionSelfCloseRef[:]))
+	of, _ := t.OptionSelfCloses.LoadOrStore(key, &OptionSelfCloseField{
+		InvestorID:         Bytes2String(field.InvestorID[:]),
+		InstrumentID:       Bytes2String(field.InstrumentID[:]),
+		OptionSelfCloseRef: Bytes2String(field.OptionSelfCloseRef[:]),
+		ExchangeID:         Bytes2String(field.ExchangeID[:]),
+		Volume:             int(field.Volume),
+		HedgeFlag:          HedgeFlagType(field.HedgeFlag),
+		OptSelfCloseFlag:   OptSelfCloseFlagType(field.OptSelfCloseFlag),
+		SessionID:          t.SessionID,
+		IsLocal:            true,
+	})
+	var f = of.(*OptionSelfCloseField)
+	f.ExecResult = ExecResultCanceled
+	f.StatusMsg = Bytes2String(info.ErrorMsg[:])
+	if t.onErrRtnSelfClose != nil {
+		t.onErrRtnSelfClose(f, &RspInfoField{ErrorID: int(info.ErrorID), ErrorMsg: Bytes2String(info.ErrorMsg[:])})
+	}
+}
+
+// ErrRtnOptionSelfCloseAction 撤销期权自对冲错误
+func (t *HFTrade) ErrRtnOptionSelfCloseAction(field *ctp.CThostFtdcOptionSelfCloseActionField, info *ctp.CThostFtdcRspInfoField) {
+	if t.IsLogin && t.onErrSelfCloseAction != nil {
+		t.onErrSelfCloseAction(fmt.Sprintf("%d_%s", field.SessionID, Bytes2String(field.OptionSelfCloseRef[:])), &RspInfoField{
+			ErrorID:  int(info.ErrorID),
+			ErrorMsg: Bytes2String(info.ErrorMsg[:]),
+		})
+	}
+}
+
+// RspQryOptionSelfClose 查期权自对冲响应
+func (t *HFTrade) RspQryOptionSelfClose(field *ctp.CThostFtdcOptionSelfCloseField) {
+	if len(Bytes2String(field.InvestorID[:])) > 0 { // 处理无记录时的空响应
+		t.RtnOptionSelfClose(field)
+	}
+}
@@ -0,0 +1,115 @@
+package goctp
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	ctp "gitee.com/haifengat/goctp/ctpdefine"
+)
+
+// SelfTradeType 自成交处理方式: 新委托与本帐号同合约的反向挂单价格交叉时
+type SelfTradeType int
+
+const (
+	SelfTradeNone   SelfTradeType = iota // 不检查
+	SelfTradeReject                      // 拒绝新委托
+	SelfTradeCancel                      // 先撤销交叉的挂单, 全部撤销(或成交)后再委托(任一撤单失败时拒绝)
+	SelfTradeAdjust                      // 调整新委托价格至对手挂单价外一个最小变动价位(市价委托拒绝)
+)
+
+// selfTradeWait 等待交叉挂单撤销后发出的委托
+type selfTradeWait struct {
+	mu      sync.Mutex
+	field   ctp.CThostFtdcInputOrderField
+	reqID   int
+	pending map[string]struct{} // 尚未撤销的交叉挂单
+}
+
+// selfTrade 按 HFTrade.SelfTrade 处理自成交, 返回(可能调整价格后的)委托请求及需先撤销的挂单, 拒绝时返回原因
+func (t *HFTrade) selfTrade(req *OrderRequest) (*OrderRequest, []string, *RspInfoField) {
+	if t.SelfTrade == SelfTradeNone {
+		return req, nil, nil
+	}
+	investor := req.InvestorID
+	if len(investor) == 0 {
+		investor = t.InvestorID
+	}
+	market := req.PriceType != 0 && req.PriceType != OrderPriceTypeLimitPrice
+	opposite := DirectionSell
+	if req.Direction == DirectionSell {
+		opposite = DirectionBuy
+	}
+	var crossed []string
+	var price float64 // 交叉挂单的最优价(买新委托为最低卖价, 卖为最高买价)
+	for id, o := range t.WorkingOrders(FilterInvestor(investor), FilterInstrument(req.InstrumentID), FilterDirection(opposite)) {
+		if !market && (req.Direction == DirectionBuy && req.LimitPrice < o.LimitPrice || req.Direction == DirectionSell && req.LimitPrice > o.LimitPrice) {
+			continue
+		}
+		if len(crossed) == 0 || req.Direction == DirectionBuy && o.LimitPrice < price || req.Direction == DirectionSell && o.LimitPrice > price {
+			price = o.LimitPrice
+		}
+		crossed = append(crossed, id)
+	}
+	if len(crossed) == 0 {
+		return req, nil, nil
+	}
+	switch t.SelfTrade {
+	case SelfTradeCancel:
+		return req, crossed, nil
+	case SelfTradeAdjust:
+		inst, ok := t.Instruments.Load(req.InstrumentID)
+		if ok && !market && inst.(*InstrumentField).PriceTick > 0 {
+			r := *req
+			tick := inst.(*InstrumentField).PriceTick
+			if r.Direction == DirectionBuy {
+				r.LimitPrice = math.Round((price-tick)/tick) * tick
+			} else {
+				r.LimitPrice = math.Round((price+tick)/tick) * tick
+			}
+			return &r, nil, nil
+		}
+	}
+	return req, nil, &RspInfoField{ErrorID: -1, ErrorMsg: fmt.Sprintf("自成交: 与挂单 %v 价格交叉", crossed)}
+}
+
+// selfTradePark 撤销交叉的挂单, 委托暂存至全部撤销(或成交)后发出
+func (t *HFTrade) selfTradePark(f *ctp.CThostFtdcInputOrderField, reqID int, crossed []string) {
+	w := &selfTradeWait{field: *f, reqID: reqID, pending: make(map[string]struct{})}
+	for _, id := range crossed {
+		w.pending[id] = struct{}{}
+	}
+	t.selfTrades.Store(fmt.Sprintf("%d_%s", t.SessionID, Bytes2String(f.OrderRef[:])), w)
+	for _, id := range crossed {
+		if ret := t.ReqOrderAction(id); ret != 0 {
+			t.selfTradeDone(id, &RspInfoField{ErrorID: ret, ErrorMsg: "撤单未能发出"})
+			return
+		}
+	}
+}
+
+// selfTradeDone 交叉挂单已完成(撤销/成交), info 不为 nil 时为撤单失败: 全部完成后发出暂存的委托, 撤单失败时拒绝
+func (t *HFTrade) selfTradeDone(orderID string, info *RspInfoField) {
+	t.selfTrades.Range(func(key, value interface{}) bool {
+		var w = value.(*selfTradeWait)
+		w.mu.Lock()
+		_, ok := w.pending[orderID]
+		delete(w.pending, orderID)
+		fire := ok && (info != nil || len(w.pending) == 0)
+		if fire { // 只处理一次
+			w.pending = nil
+		}
+		w.mu.Unlock()
+		if !fire {
+			return true
+		}
+		t.selfTrades.Delete(key)
+		if info != nil {
+			t.rejectOrder(&w.field, &RspInfoField{ErrorID: info.ErrorID, ErrorMsg: fmt.Sprintf("自成交: 撤销挂单 %s 失败: %s", orderID, info.ErrorMsg)})
+		} else {
+			t.orderSent(&w.field)
+			t.ReqOrder(&w.field, w.reqID)
+		}
+		return true
+	})
+}
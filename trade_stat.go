@@ -0,0 +1,139 @@
+package goctp
+
+import "sync/atomic"
+
+// SetCancelLimit 合约当日撤单数(Orders 中已撤销且非错单的委托, 含登录时回放/查询的委托及撤单中的委托)达到 limit 后 ReqOrderAction 不再撤单(返回 -2), 0 不限
+// 交易所撤单上限为 500 时可设为 480 等留有余量的值
+func (t *HFTrade) SetCancelLimit(limit int) {
+	t.cancelLimit = limit
+}
+
+// OrderStat 合约当日委托统计(由 RtnOrder/RtnTrade 累计)
+func (t *HFTrade) OrderStat(instrument string) *OrderStatField {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	s := t.orderStat(instrument).OrderStatField
+	return &s
+}
+
+// OrderStats 全部合约的当日委托统计 (key: InstrumentID)
+func (t *HFTrade) OrderStats() map[string]*OrderStatField {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	stats := make(map[string]*OrderStatField, len(t.stats))
+	for inst, s := range t.stats {
+		f := s.OrderStatField
+		stats[inst] = &f
+	}
+	return stats
+}
+
+// RiskOrderStats 风控: 合约当日撤单/错单/自成交数达到限制后拒绝委托, 0 不限
+func (t *HFTrade) RiskOrderStats(cancels, rejects, selfTrades int) RiskRuleType {
+	return func(req *OrderRequest) *RspInfoField {
+		stat := t.OrderStat(req.InstrumentID)
+		switch {
+		case cancels > 0 && stat.Cancels >= cancels:
+			return riskInfo("撤单数 %d 达到限制 %d", stat.Cancels, cancels)
+		case rejects > 0 && stat.Rejects >= rejects:
+			return riskInfo("错单数 %d 达到限制 %d", stat.Rejects, rejects)
+		case selfTrades > 0 && stat.SelfTrades >= selfTrades:
+			return riskInfo("自成交数 %d 达到限制 %d", stat.SelfTrades, selfTrades)
+		}
+		return nil
+	}
+}
+
+// instrumentStat 合约统计及撤单中的数量
+type instrumentStat struct {
+	OrderStatField
+	canceling int // 已发出撤单尚未完成的委托数
+}
+
+// orderStat 合约的统计, 不存在时添加(statMu)
+func (t *HFTrade) orderStat(instrument string) *instrumentStat {
+	if t.stats == nil {
+		t.stats = make(map[string]*instrumentStat)
+		t.canceling = make(map[string]string)
+	}
+	s, ok := t.stats[instrument]
+	if !ok {
+		s = &instrumentStat{OrderStatField: OrderStatField{TradingDay: t.TradingDay, InstrumentID: instrument}}
+		t.stats[instrument] = s
+	}
+	return s
+}
+
+// statOrder 新委托
+func (t *HFTrade) statOrder(instrument string) {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	t.orderStat(instrument).Orders++
+}
+
+// statCanceled 委托已撤销(含回放), rejected: 错单, 否则计入撤单数
+func (t *HFTrade) statCanceled(key, instrument string, rejected bool) {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	s := t.orderStat(instrument)
+	if rejected {
+		s.Rejects++
+	} else {
+		s.Cancels++
+	}
+	if _, ok := t.canceling[key]; ok {
+		delete(t.canceling, key)
+		s.canceling--
+	}
+}
+
+// statReset 交易日切换时清空统计
+func (t *HFTrade) statReset() {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	t.stats = nil
+	t.canceling = nil
+}
+
+// statSelfTrade 自成交
+func (t *HFTrade) statSelfTrade(instrument string) {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	t.orderStat(instrument).SelfTrades++
+}
+
+// statActions 发出撤单前检查撤单上限(含撤单中的委托), 通过时记为撤单中; 已在撤单中的委托不重复计数
+func (t *HFTrade) statActions(orders map[string]string) bool {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	adds := make(map[string]int)
+	for key, inst := range orders {
+		if _, ok := t.canceling[key]; !ok {
+			adds[inst]++
+		}
+	}
+	for inst, n := range adds {
+		if s := t.orderStat(inst); t.cancelLimit > 0 && atomic.LoadInt32(&t.killing) == 0 && s.Cancels+s.canceling+n > t.cancelLimit {
+			return false
+		}
+	}
+	for key, inst := range orders {
+		if _, ok := t.canceling[key]; !ok {
+			t.canceling[key] = inst
+			t.orderStat(inst).canceling++
+		}
+	}
+	return true
+}
+
+// statActionDone 撤单中的委托未撤销: 成交或撤单被拒绝
+func (t *HFTrade) statActionDone(key string) {
+	t.statMu.Lock()
+	defer t.statMu.Unlock()
+	inst, ok := t.canceling[key]
+	if !ok {
+		return
+	}
+	delete(t.canceling, key)
+	t.orderStat(inst).canceling--
+}
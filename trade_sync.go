@@ -0,0 +1,136 @@
+package goctp
+
+import (
+	"context"
+	"fmt"
+)
+
+// Login 同步登录(需已 ReqConnect 并连接到前置), 登录流程(认证/登录/确认结算/查询)完成后返回, ctx 结束时返回 ctx.Err()
+func (t *HFTrade) Login(ctx context.Context, user, pwd, broker, appID, authCode string) (*RspUserLoginField, error) {
+	id := t.getReqID()
+	t.logins.Store(id, (*RspUserLoginField)(nil))
+	defer t.logins.Delete(id)
+	err := t.wait(ctx, id, func() {
+		t.reqLogin(user, pwd, broker, appID, authCode, id)
+	}, func(info *RspInfoField) bool { return info != nil })
+	if err != nil {
+		return nil, err
+	}
+	field, _ := t.logins.Load(id)
+	return field.(*RspUserLoginField), nil
+}
+
+// QryInstrument 同步查询合约(同时更新 Instruments). 经查询队列与持仓/资金循环查询依次发出, 未登录或已 Release 时返回错误
+func (t *HFTrade) QryInstrument(ctx context.Context) ([]*InstrumentField, error) {
+	id := t.getReqID()
+	var insts []*InstrumentField
+	t.qryInstruments.Store(id, &insts)
+	defer t.qryInstruments.Delete(id)
+	var qerr error
+	err := t.wait(ctx, id, func() {
+		qerr = t.qry(func() { t.reqQryInstrument(id) })
+	}, func(info *RspInfoField) bool { return qerr != nil || info != nil })
+	if qerr != nil {
+		return nil, qerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return insts, nil
+}
+
+// InsertOrder 同步委托, 交易所确认(有 OrderSysID)或委托完成后返回; 被拒绝时返回委托及错误
+func (t *HFTrade) InsertOrder(ctx context.Context, req *OrderRequest) (*OrderField, error) {
+	id := t.ReqOrderInsertRequest(req)
+	return t.waitOrder(ctx, id, nil, func(o *OrderField) bool {
+		return o != nil && (len(o.OrderSysID) > 0 || o.OrderStatus == OrderStatusAllTraded || o.OrderStatus == OrderStatusCanceled)
+	})
+}
+
+// CancelOrder 同步撤单, 委托撤销或全部成交后返回; 委托不存在/撤单数达到上限/撤单被拒绝时返回错误
+func (t *HFTrade) CancelOrder(ctx context.Context, orderID string) (*OrderField, error) {
+	var ret int
+	o, err := t.waitOrder(ctx, orderID, func() {
+		ret = t.ReqOrderAction(orderID)
+	}, func(o *OrderField) bool {
+		return ret != 0 || o != nil && (o.OrderStatus == OrderStatusAllTraded || o.OrderStatus == OrderStatusCanceled)
+	})
+	switch ret {
+	case -1:
+		return o, fmt.Errorf("[%d] 委托不存在", ret)
+	case -2:
+		return o, fmt.Errorf("[%d] 撤单数达到上限", ret)
+	}
+	return o, err
+}
+
+// waitOrder 发出请求后等待委托满足 done(委托未确认时为 nil), 委托被拒绝时返回错误
+func (t *HFTrade) waitOrder(ctx context.Context, orderID string, send func(), done func(*OrderField) bool) (o *OrderField, err error) {
+	load := func() *OrderField {
+		if of, ok := t.Orders.Load(orderID); ok {
+			o = of.(*OrderField)
+		}
+		return o
+	}
+	err = t.wait(ctx, orderID, send, func(info *RspInfoField) bool {
+		return done(load())
+	})
+	if load(); err == nil && orderRejected(o) {
+		err = fmt.Errorf("[%d] %s", -1, o.StatusMsg)
+	}
+	return
+}
+
+// wait 注册 id 的响应后调用 send, 等待 done 为 true(之前每次响应时检查), 响应错误或 ctx 结束时返回错误
+func (t *HFTrade) wait(ctx context.Context, id interface{}, send func(), done func(info *RspInfoField) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ch := make(chan *RspInfoField, 16)
+	t.waiters.Store(ch, id)
+	defer t.waiters.Delete(ch)
+	if send != nil {
+		send()
+	}
+	var info *RspInfoField
+	for !done(info) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case info = <-ch:
+			if info != nil && info.ErrorID != 0 {
+				return fmt.Errorf("[%d] %s", info.ErrorID, info.ErrorMsg)
+			}
+		}
+	}
+	return nil
+}
+
+// notify 响应同步请求, info 为 nil 时只触发检查
+func (t *HFTrade) notify(id interface{}, info *RspInfoField) {
+	t.waiters.Range(func(key, value interface{}) bool {
+		if value == id {
+			select {
+			case key.(chan *RspInfoField) <- info:
+			default:
+			}
+		}
+		return true
+	})
+}
+
+// rspUserLogin 登录响应(RegOnRspUserLogin 及以 RequestID 对应的同步登录)
+func (t *HFTrade) rspUserLogin(reqID int, field *RspUserLoginField, info *RspInfoField) {
+	if _, ok := t.logins.Load(reqID); ok { // 同步登录
+		t.logins.Store(reqID, field)
+	}
+	if t.onRspUserLogin != nil {
+		t.onRspUserLogin(field, info)
+	}
+	t.notify(reqID, info)
+}
+
+// orderRejected 错单: 未到交易所(ErrRtnOrderInsert/本地风控)或交易所拒绝
+func orderRejected(o *OrderField) bool {
+	return o != nil && o.OrderStatus == OrderStatusCanceled && o.OrderSubmitStatus == OrderSubmitStatusInsertRejected
+}
@@ -81,6 +81,27 @@ func NewQuote() *Quote {
 		}
 		q.h.MustFindProc("qSubscribeMarketData").Call(q.api, uintptr(unsafe.Pointer(&ppInstrumentID)), uintptr(len(instrument)))
 	}
+	q.HFQuote.ReqUnSubMarketData = func(instrument ...string) {
+		ppInstrumentID := make([]*byte, len(instrument))
+		for i := 0; i < len(instrument); i++ {
+			ppInstrumentID[i], _ = syscall.BytePtrFromString(instrument[i])
+		}
+		q.h.MustFindProc("qUnSubscribeMarketData").Call(q.api, uintptr(unsafe.Pointer(&ppInstrumentID[0])), uintptr(len(instrument)))
+	}
+	q.HFQuote.ReqSubForQuote = func(instrument ...string) {
+		ppInstrumentID := make([]*byte, len(instrument))
+		for i := 0; i < len(instrument); i++ {
+			ppInstrumentID[i], _ = syscall.BytePtrFromString(instrument[i])
+		}
+		q.h.MustFindProc("qSubscribeForQuoteRsp").Call(q.api, uintptr(unsafe.Pointer(&ppInstrumentID[0])), uintptr(len(instrument)))
+	}
+	q.HFQuote.ReqUnSubForQuote = func(instrument ...string) {
+		ppInstrumentID := make([]*byte, len(instrument))
+		for i := 0; i < len(instrument); i++ {
+			ppInstrumentID[i], _ = syscall.BytePtrFromString(instrument[i])
+		}
+		q.h.MustFindProc("qUnSubscribeForQuoteRsp").Call(q.api, uintptr(unsafe.Pointer(&ppInstrumentID[0])), uintptr(len(instrument)))
+	}
 
 	// HFQuote 响应  手动添加即可增加新功能
 	q._RtnDepthMarketData = func(f *ctp.CThostFtdcDepthMarketDataField) {
@@ -89,6 +110,15 @@ func NewQuote() *Quote {
 	q._RspUserLogin = func(f *ctp.CThostFtdcRspUserLoginField, i *ctp.CThostFtdcRspInfoField, n int, b bool) {
 		q.HFQuote.RspUserLogin(f, i)
 	}
+	q._RspSubMarketData = func(f *ctp.CThostFtdcSpecificInstrumentField, i *ctp.CThostFtdcRspInfoField, n int, b bool) {
+		q.HFQuote.RspSubMarketData(f, i)
+	}
+	q._RspUnSubMarketData = func(f *ctp.CThostFtdcSpecificInstrumentField, i *ctp.CThostFtdcRspInfoField, n int, b bool) {
+		q.HFQuote.RspUnSubMarketData(f, i)
+	}
+	q._RtnForQuoteRsp = func(f *ctp.CThostFtdcForQuoteRspField) {
+		q.HFQuote.RtnForQuoteRsp(f)
+	}
 	q._FrontConnected = func() {
 		q.HFQuote.FrontConnected()
 	}